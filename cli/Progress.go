@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/reaandrew/schmokin/server"
+	"github.com/reaandrew/schmokin/service"
+)
+
+// How often the progress is refreshed on a terminal and logged otherwise.
+const (
+	ProgressInterval    = time.Second
+	ProgressLogInterval = 10 * time.Second
+)
+
+// ProgressReporter shows the progress of a run. On a terminal one line is
+// refreshed in place, otherwise a plain line is logged periodically.
+type ProgressReporter struct {
+	writer   io.Writer
+	logger   *log.Logger
+	expected time.Duration
+	// The transactions and elapsed time of the last report, to work out
+	// the current transaction rate, and when the warm-up was last seen.
+	transactions int
+	reported     time.Duration
+	logged       time.Duration
+	warmedUp     time.Duration
+}
+
+// NewProgressReporter creates a reporter writing to the writer, the
+// expected duration of the run gives the time remaining when it is known.
+func NewProgressReporter(writer io.Writer, terminal bool, expected time.Duration) *ProgressReporter {
+	reporter := &ProgressReporter{
+		writer:   writer,
+		expected: expected,
+	}
+	if !terminal {
+		reporter.logger = log.New(writer, "", log.LstdFlags)
+	}
+	return reporter
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Report shows the progress of the run the elapsed time after it started.
+func (reporter *ProgressReporter) Report(elapsed time.Duration, progress service.Progress) {
+	var rate, averageRate float64
+	if seconds := (elapsed - reporter.reported).Seconds(); seconds > 0 {
+		rate = float64(progress.Transactions-reporter.transactions) / seconds
+	}
+	if progress.WarmingUp {
+		reporter.warmedUp = elapsed
+	} else if seconds := (elapsed - reporter.warmedUp).Seconds(); seconds > 0 {
+		averageRate = float64(progress.Transactions) / seconds
+	}
+	reporter.transactions = progress.Transactions
+	reporter.reported = elapsed
+
+	fields := []string{fmt.Sprintf("Elapsed %v", elapsed.Round(time.Second))}
+	if reporter.expected > 0 {
+		remaining := reporter.expected - elapsed
+		if remaining < 0 {
+			remaining = 0
+		}
+		fields = append(fields, fmt.Sprintf("Remaining %v", remaining.Round(time.Second)))
+	}
+	if progress.WarmingUp {
+		fields = append(fields, "Warming up")
+	}
+	fields = append(fields,
+		fmt.Sprintf("Active Users %d", progress.ActiveUsers),
+		fmt.Sprintf("Transactions %d", progress.Transactions),
+		fmt.Sprintf("Rate %.2f/s (average %.2f/s)", rate, averageRate),
+		fmt.Sprintf("Failures %.2f%%", outcomePercentage(progress.FailedTransactions, progress.Transactions)),
+		fmt.Sprintf("Errors %.2f%%", outcomePercentage(progress.ErroredTransactions, progress.Transactions)),
+		fmt.Sprintf("p95 %.2fms", float64(progress.ResponseTime.P95)/float64(time.Millisecond)),
+	)
+	line := strings.Join(fields, ", ")
+
+	if reporter.logger == nil {
+		fmt.Fprintf(reporter.writer, "\r\033[K%v", line)
+		return
+	}
+	if reporter.logged == 0 || elapsed-reporter.logged >= ProgressLogInterval {
+		reporter.logger.Println(line)
+		reporter.logged = elapsed
+	}
+}
+
+// Finish ends the line refreshed on a terminal so the last progress is
+// left above whatever is written next.
+func (reporter *ProgressReporter) Finish() {
+	if reporter.logger == nil && reporter.reported > 0 {
+		fmt.Fprintln(reporter.writer)
+	}
+}
+
+func outcomePercentage(count int64, transactions int) float64 {
+	if transactions == 0 {
+		return 0
+	}
+	return float64(count) / float64(transactions) * 100
+}
+
+// expectedDuration returns how long the run is expected to last, zero
+// when it runs for a number of iterations instead.
+func (schmokinCLI *SchmokinCLI) expectedDuration() time.Duration {
+	expected := schmokinCLI.duration
+	for _, stage := range schmokinCLI.stages {
+		expected += stage.Duration
+	}
+	if expected == 0 {
+		return 0
+	}
+	return expected + schmokinCLI.warmup.Duration
+}
+
+// workerProgress returns a snapshot of the run across every worker
+// process from the last progress each streamed.
+func workerProgress(collectors []*server.RunCollector) (service.Progress, error) {
+	responses := []*server.ProgressResponse{}
+	for _, collector := range collectors {
+		if progress := collector.Progress(); progress != nil {
+			responses = append(responses, progress)
+		}
+	}
+	return server.MergeProgress(responses)
+}
+
+// reportProgress shows the progress the worker processes stream until
+// the returned function is called.
+func (schmokinCLI *SchmokinCLI) reportProgress(collectors []*server.RunCollector) func() {
+	if !schmokinCLI.progress {
+		return func() {}
+	}
+	var reporter *ProgressReporter
+	if isTerminal(os.Stdout) {
+		reporter = NewProgressReporter(os.Stdout, true, schmokinCLI.expectedDuration())
+	} else {
+		reporter = NewProgressReporter(os.Stderr, false, schmokinCLI.expectedDuration())
+	}
+	start := time.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				reporter.Finish()
+				return
+			case now := <-ticker.C:
+				progress, err := workerProgress(collectors)
+				if err != nil {
+					log.Println(err)
+					continue
+				}
+				reporter.Report(now.Sub(start), progress)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,48 @@
+package cli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/cli"
+	"github.com/reaandrew/schmokin/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProgressReporterRefreshesALineOnATerminal(t *testing.T) {
+	var output bytes.Buffer
+	reporter := cli.NewProgressReporter(&output, true, time.Minute)
+
+	reporter.Report(time.Second, service.Progress{Transactions: 100, ActiveUsers: 10})
+	reporter.Report(2*time.Second, service.Progress{
+		Transactions:        300,
+		FailedTransactions:  30,
+		ErroredTransactions: 3,
+		ActiveUsers:         10,
+		ResponseTime:        service.Distribution{P95: int64(12500 * time.Microsecond)},
+	})
+	reporter.Finish()
+
+	lines := strings.Split(output.String(), "\r\033[K")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "Elapsed 2s, Remaining 58s, Active Users 10, Transactions 300, Rate 200.00/s (average 150.00/s), Failures 10.00%, Errors 1.00%, p95 12.50ms\n", lines[2])
+}
+
+func Test_ProgressReporterLogsPeriodicallyElsewhere(t *testing.T) {
+	var output bytes.Buffer
+	reporter := cli.NewProgressReporter(&output, false, 0)
+
+	for elapsed := time.Second; elapsed <= 25*time.Second; elapsed += time.Second {
+		reporter.Report(elapsed, service.Progress{WarmingUp: elapsed <= 5*time.Second})
+	}
+	reporter.Finish()
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "Elapsed 1s, Warming up, Active Users 0")
+	assert.Contains(t, lines[1], "Elapsed 11s, Active Users 0")
+	assert.Contains(t, lines[2], "Elapsed 21s")
+	assert.NotContains(t, output.String(), "\r")
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
 	"github.com/reaandrew/schmokin/server"
 	"github.com/reaandrew/schmokin/service"
 	"github.com/reaandrew/schmokin/utils"
@@ -22,9 +24,11 @@ import (
 type SchmokinServiceClientConnection struct {
 	Client     server.SchmokinServiceClient
 	Connection *grpc.ClientConn
+	Process    *os.Process
 }
 
 type SchmokinCLI struct {
+	lock    sync.Mutex
 	workers []SchmokinServiceClientConnection
 	//TODO: Create a configuration struct for these
 	urlFilePath string
@@ -35,6 +39,22 @@ type SchmokinCLI struct {
 	random      bool
 	workerCount int
 	iterations  int
+	duration    time.Duration
+	stages      []service.Stage
+	rate        float64
+	maxWorkers  int
+	poisson     bool
+	warmup      service.Warmup
+	interval    time.Duration
+	progress    bool
+	thresholds  []service.Threshold
+	abortOnFail bool
+	headers     []string
+	timeout     time.Duration
+	tls         schmokinHTTP.TLSOptions
+	cookieJar   string
+	saveCookies bool
+	captureSize int64
 }
 
 const SchmokinPathVar = "SCHMOKIN_PATH"
@@ -89,18 +109,18 @@ func (schmokinCLI *SchmokinCLI) StartServer(port int) SchmokinServiceClientConne
 	return SchmokinServiceClientConnection{
 		Connection: conn,
 		Client:     client,
+		Process:    cmd.Process,
 	}
 }
 
-func (schmokinCLI *SchmokinCLI) RunServer() (result *service.SchmokinResult, err error) {
+func (schmokinCLI *SchmokinCLI) RunServer(ctx context.Context) (result *service.SchmokinResult, err error) {
 	log.Println(fmt.Sprintf("Starting server %s %d", schmokinCLI.serverHost, schmokinCLI.serverPort))
-	server.StartServer(fmt.Sprintf("%v:%v", schmokinCLI.serverHost, schmokinCLI.serverPort))
+	server.StartServer(ctx, fmt.Sprintf("%v:%v", schmokinCLI.serverHost, schmokinCLI.serverPort))
 	return &service.SchmokinResult{}, nil
 }
 
 func (schmokinCLI *SchmokinCLI) StartWorkerProcesses() {
 	var wg = sync.WaitGroup{}
-	var syncLock = sync.Mutex{}
 	for i := 0; i < schmokinCLI.processes; i++ {
 		wg.Add(1)
 		// This needs to use some sort of freeport package to find any port which is going
@@ -108,42 +128,109 @@ func (schmokinCLI *SchmokinCLI) StartWorkerProcesses() {
 		portNumber := 54322 + i
 		go func(port int) {
 			connection := schmokinCLI.StartServer(port)
-			syncLock.Lock()
+			schmokinCLI.lock.Lock()
 			schmokinCLI.workers = append(schmokinCLI.workers, connection)
-			syncLock.Unlock()
+			schmokinCLI.lock.Unlock()
 			wg.Done()
 		}(portNumber)
 	}
 	wg.Wait()
 }
 
+// ExecuteWorkerProcesses runs the lines on every worker process, showing
+// the progress they stream while they run. Cancelling the context
+// interrupts the workers rather than cancelling their runs so they still
+// return the results of the transactions made until then. A worker process
+// which fails part way through gives the last result it streamed.
 func (schmokinCLI *SchmokinCLI) ExecuteWorkerProcesses(ctx context.Context, lines []string) (responses []*server.SchmokinResponse) {
 	var wg = sync.WaitGroup{}
-	var lock = sync.Mutex{}
-	for _, connection := range schmokinCLI.workers {
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			schmokinCLI.InterruptWorkerProcesses()
+		case <-finished:
+		}
+	}()
+	collectors := []*server.RunCollector{}
+	for index, connection := range schmokinCLI.workers {
+		collector := &server.RunCollector{}
+		collectors = append(collectors, collector)
 		wg.Add(1)
-		go func(connection SchmokinServiceClientConnection) {
-			response, err := connection.Client.Run(ctx, &server.SchmokinRequest{
-				Iterations:  int32(schmokinCLI.iterations),
-				Lines:       lines,
-				Random:      schmokinCLI.random,
-				WorkerCount: int32(schmokinCLI.workerCount),
+		go func(index int, connection SchmokinServiceClientConnection) {
+			defer wg.Done()
+			warmup := service.SplitWarmup(schmokinCLI.warmup, index, len(schmokinCLI.workers))
+			stream, err := connection.Client.RunStream(context.Background(), &server.SchmokinRequest{
+				Iterations:       int32(schmokinCLI.iterations),
+				Duration:         int64(schmokinCLI.duration),
+				Stages:           server.NewStages(service.SplitStages(schmokinCLI.stages, index, len(schmokinCLI.workers))),
+				Rate:             schmokinCLI.rate / float64(len(schmokinCLI.workers)),
+				MaxWorkers:       int32(service.SplitMaxWorkers(schmokinCLI.maxWorkers, index, len(schmokinCLI.workers))),
+				Poisson:          schmokinCLI.poisson,
+				WarmupDuration:   int64(warmup.Duration),
+				WarmupIterations: int32(warmup.Iterations),
+				Interval:         int64(schmokinCLI.interval),
+				Lines:            lines,
+				Random:           schmokinCLI.random,
+				WorkerCount:      int32(schmokinCLI.workerCount),
+				Headers:          schmokinCLI.headers,
+				Timeout:          int64(schmokinCLI.timeout),
+				Insecure:         schmokinCLI.tls.Insecure,
+				Cacert:           schmokinCLI.tls.CACert,
+				Cert:             schmokinCLI.tls.Cert,
+				Key:              schmokinCLI.tls.Key,
+				TlsMinVersion:    schmokinCLI.tls.MinVersion,
+				ServerName:       schmokinCLI.tls.ServerName,
+				CookieJar:        schmokinCLI.cookieJar,
+				SaveCookies:      schmokinCLI.saveCookies,
+				Process:          int32(index),
+				CaptureSize:      schmokinCLI.captureSize,
 			})
-			lock.Lock()
-			responses = append(responses, response)
-			lock.Unlock()
+			if err == nil {
+				err = collector.Collect(stream)
+			}
 			if err != nil {
-				fmt.Println(connection.Connection.GetState())
-				panic(err)
+				log.Printf("Worker process %d failed, keeping the last result it sent: %v", index+1, err)
 			}
-			wg.Done()
-		}(connection)
+		}(index, connection)
 	}
 
+	stopProgress := schmokinCLI.reportProgress(collectors)
+	stopAborting := schmokinCLI.abortOnThresholds(collectors)
 	wg.Wait()
+	stopAborting()
+	stopProgress()
+	for _, collector := range collectors {
+		if response := collector.Response(); response != nil {
+			responses = append(responses, response)
+		}
+	}
 	return
 }
 
+// InterruptWorkerProcesses asks every worker process to stop starting
+// transactions and return once those in flight have finished.
+func (schmokinCLI *SchmokinCLI) InterruptWorkerProcesses() {
+	for _, connection := range schmokinCLI.workers {
+		if _, err := connection.Client.Interrupt(context.Background(), &empty.Empty{}); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// KillWorkerProcesses kills every worker process started, for when the
+// controller has to exit without waiting for them.
+func (schmokinCLI *SchmokinCLI) KillWorkerProcesses() {
+	schmokinCLI.lock.Lock()
+	defer schmokinCLI.lock.Unlock()
+	for _, connection := range schmokinCLI.workers {
+		if err := connection.Process.Kill(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
 func (schmokinCLI *SchmokinCLI) StopWorkerProcesses(ctx context.Context) {
 	var wg = sync.WaitGroup{}
 	for _, connection := range schmokinCLI.workers {
@@ -162,37 +249,80 @@ func (schmokinCLI *SchmokinCLI) StopWorkerProcesses(ctx context.Context) {
 	wg.Wait()
 }
 
-func (schmokinCLI *SchmokinCLI) RunController() (result *service.SchmokinResult, err error) {
+func (schmokinCLI *SchmokinCLI) validateCookieJar() error {
+	if schmokinCLI.cookieJar == "" {
+		if schmokinCLI.saveCookies {
+			return errors.New("saving cookies requires a cookie jar")
+		}
+		return nil
+	}
+	file, err := os.Open(schmokinCLI.cookieJar)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return schmokinHTTP.NewCookieJar().Load(file)
+}
+
+// RunController runs the urls file on the worker processes and merges
+// their results, cancelling the context interrupts the run and returns the
+// partial result.
+func (schmokinCLI *SchmokinCLI) RunController(ctx context.Context) (result *service.SchmokinResult, err error) {
 	var lines []string
 
 	if schmokinCLI.urlFilePath == "" {
 		panic("No URL file supplied")
 	}
 
+	if schmokinCLI.duration < 0 {
+		return nil, errors.New("the duration must not be negative")
+	}
+
+	if schmokinCLI.duration > 0 && len(schmokinCLI.stages) > 0 {
+		return nil, errors.New("a duration cannot be used with stages")
+	}
+
+	if schmokinCLI.rate < 0 {
+		return nil, errors.New("the rate must not be negative")
+	}
+
+	if schmokinCLI.rate > 0 && schmokinCLI.duration == 0 && len(schmokinCLI.stages) == 0 {
+		return nil, errors.New("a rate needs a duration or stages")
+	}
+
+	if _, err = schmokinHTTP.BuildHeader(schmokinCLI.headers, nil); err != nil {
+		return
+	}
+
+	if _, err = schmokinCLI.tls.Config(); err != nil {
+		return
+	}
+
+	if err = schmokinCLI.validateCookieJar(); err != nil {
+		return
+	}
+
 	lines, err = utils.ReadFileToLines(schmokinCLI.urlFilePath)
 	if err != nil {
-		panic(err)
+		return
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
-	fmt.Println("Starting the worker processes...")
+	fmt.Fprintln(os.Stderr, "Starting the worker processes...")
 	schmokinCLI.StartWorkerProcesses()
 
-	fmt.Println("Surging...")
+	fmt.Fprintln(os.Stderr, "Surging...")
 	responses := schmokinCLI.ExecuteWorkerProcesses(ctx, lines)
 
-	fmt.Println("Stopping the worker processes...")
-	schmokinCLI.StopWorkerProcesses(ctx)
+	fmt.Fprintln(os.Stderr, "Stopping the worker processes...")
+	schmokinCLI.StopWorkerProcesses(context.Background())
 
-	result = server.MergeResponses(responses)
-	return
+	return server.MergeResponses(responses)
 }
 
-func (schmokinCLI *SchmokinCLI) Run() (result *service.SchmokinResult, err error) {
+func (schmokinCLI *SchmokinCLI) Run(ctx context.Context) (result *service.SchmokinResult, err error) {
 	if schmokinCLI.server {
-		return schmokinCLI.RunServer()
+		return schmokinCLI.RunServer(ctx)
 	}
 
-	return schmokinCLI.RunController()
+	return schmokinCLI.RunController(ctx)
 }
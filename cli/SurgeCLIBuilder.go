@@ -1,5 +1,12 @@
 package cli
 
+import (
+	"time"
+
+	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
+	"github.com/reaandrew/schmokin/service"
+)
+
 type SchmokinCLIBuilder struct {
 	cli *SchmokinCLI
 }
@@ -27,11 +34,91 @@ func (builder *SchmokinCLIBuilder) SetIterations(count int) *SchmokinCLIBuilder
 	return builder
 }
 
+func (builder *SchmokinCLIBuilder) SetDuration(duration time.Duration) *SchmokinCLIBuilder {
+	builder.cli.duration = duration
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetStages(stages []service.Stage) *SchmokinCLIBuilder {
+	builder.cli.stages = stages
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetRate(rate float64) *SchmokinCLIBuilder {
+	builder.cli.rate = rate
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetMaxWorkers(count int) *SchmokinCLIBuilder {
+	builder.cli.maxWorkers = count
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetPoisson(value bool) *SchmokinCLIBuilder {
+	builder.cli.poisson = value
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetWarmup(warmup service.Warmup) *SchmokinCLIBuilder {
+	builder.cli.warmup = warmup
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetInterval(interval time.Duration) *SchmokinCLIBuilder {
+	builder.cli.interval = interval
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetProgress(value bool) *SchmokinCLIBuilder {
+	builder.cli.progress = value
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetThresholds(thresholds []service.Threshold) *SchmokinCLIBuilder {
+	builder.cli.thresholds = thresholds
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetAbortOnThreshold(value bool) *SchmokinCLIBuilder {
+	builder.cli.abortOnFail = value
+	return builder
+}
+
 func (builder *SchmokinCLIBuilder) SetRandom(value bool) *SchmokinCLIBuilder {
 	builder.cli.random = value
 	return builder
 }
 
+func (builder *SchmokinCLIBuilder) SetHeaders(headers []string) *SchmokinCLIBuilder {
+	builder.cli.headers = headers
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetTimeout(timeout time.Duration) *SchmokinCLIBuilder {
+	builder.cli.timeout = timeout
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetTLSOptions(options schmokinHTTP.TLSOptions) *SchmokinCLIBuilder {
+	builder.cli.tls = options
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetCookieJar(path string) *SchmokinCLIBuilder {
+	builder.cli.cookieJar = path
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetSaveCookies(value bool) *SchmokinCLIBuilder {
+	builder.cli.saveCookies = value
+	return builder
+}
+
+func (builder *SchmokinCLIBuilder) SetCaptureSize(size int64) *SchmokinCLIBuilder {
+	builder.cli.captureSize = size
+	return builder
+}
+
 func (builder *SchmokinCLIBuilder) SetURLFilePath(value string) *SchmokinCLIBuilder {
 	builder.cli.urlFilePath = value
 	return builder
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"log"
+	"time"
+
+	"github.com/reaandrew/schmokin/server"
+	"github.com/reaandrew/schmokin/service"
+)
+
+// abortOnThresholds checks the result the worker processes have streamed
+// so far against the thresholds which cannot pass again once they have
+// failed, interrupting the run as soon as one fails. It stops when the
+// returned function is called.
+func (schmokinCLI *SchmokinCLI) abortOnThresholds(collectors []*server.RunCollector) func() {
+	thresholds := []service.Threshold{}
+	for _, threshold := range schmokinCLI.thresholds {
+		if threshold.Irrecoverable() {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	if !schmokinCLI.abortOnFail || len(thresholds) == 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(server.SnapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				responses := []*server.SchmokinResponse{}
+				for _, collector := range collectors {
+					if response := collector.Response(); response != nil {
+						responses = append(responses, response)
+					}
+				}
+				result, err := server.MergeResponses(responses)
+				if err != nil {
+					log.Println(err)
+					continue
+				}
+				for _, threshold := range thresholds {
+					// An endpoint without transactions yet can still pass
+					if evaluated := threshold.Evaluate(*result); !evaluated.Passed && !evaluated.Missing {
+						log.Printf("The threshold %v failed, aborting the run", threshold.Expression)
+						schmokinCLI.InterruptWorkerProcesses()
+						return
+					}
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// stringArrayFlag binds a string array flag to viper, which reads a string
+// array flag as one string but splits a string slice flag into its values.
+// The values are written as CSV so those with a comma are kept whole.
+type stringArrayFlag struct {
+	flag *pflag.Flag
+}
+
+func (value stringArrayFlag) HasChanged() bool {
+	return value.flag.Changed
+}
+
+func (value stringArrayFlag) Name() string {
+	return value.flag.Name
+}
+
+func (value stringArrayFlag) ValueType() string {
+	return "stringSlice"
+}
+
+func (value stringArrayFlag) ValueString() string {
+	var buffer bytes.Buffer
+	w := csv.NewWriter(&buffer)
+	if values := value.flag.Value.(pflag.SliceValue).GetSlice(); len(values) > 0 {
+		w.Write(values)
+		w.Flush()
+	}
+	return "[" + strings.TrimSuffix(buffer.String(), "\n") + "]"
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringArrayFlag(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringArray("threshold", []string{}, "")
+	config := viper.New()
+	config.BindFlagValue("thresholds", stringArrayFlag{flags.Lookup("threshold")})
+
+	assert.Empty(t, config.GetStringSlice("thresholds"))
+
+	assert.Nil(t, flags.Parse([]string{"--threshold", "GET /users:p95<300ms", "--threshold", "GET /a,b:errored==0"}))
+
+	assert.Equal(t, []string{"GET /users:p95<300ms", "GET /a,b:errored==0"}, config.GetStringSlice("thresholds"))
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/reaandrew/schmokin/service"
+)
+
+// JUnitTestSuites is the root of a JUnit XML report.
+type JUnitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds the test cases of one run.
+type JUnitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       float64         `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []JUnitProperty `xml:"properties>property"`
+	TestCases  []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitProperty is a name and value describing a run.
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitTestCase is an endpoint or a threshold, with a failure when it did
+// not pass. The time of an endpoint is the sum of the response times of
+// its transactions.
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure holds why a test case failed.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitReport builds a JUnit XML report of the run with a test case for
+// each endpoint, which fails when any transaction to it failed or errored,
+// and one for each threshold.
+func junitReport(config ReportConfig, result *service.SchmokinResult, thresholds []service.ThresholdResult) JUnitTestSuites {
+	suite := JUnitTestSuite{
+		Name:      "schmokin",
+		Time:      result.ElapsedTime.Seconds(),
+		Timestamp: result.StartTime.UTC().Format("2006-01-02T15:04:05"),
+		Properties: []JUnitProperty{
+			{Name: "urls", Value: config.URLFile},
+			{Name: "workers", Value: fmt.Sprintf("%v", config.Workers)},
+			{Name: "iterations", Value: fmt.Sprintf("%v", config.Iterations)},
+			{Name: "processes", Value: fmt.Sprintf("%v", config.Processes)},
+			{Name: "random", Value: fmt.Sprintf("%v", config.Random)},
+			{Name: "transactions", Value: fmt.Sprintf("%v", result.Transactions)},
+			{Name: "interrupted", Value: fmt.Sprintf("%v", result.Interrupted)},
+		},
+	}
+	if result.Warmup.Transactions > 0 {
+		suite.Properties = append(suite.Properties, JUnitProperty{Name: "warmup_transactions", Value: fmt.Sprintf("%v", result.Warmup.Transactions)})
+	}
+	for _, endpoint := range result.Endpoints {
+		testCase := JUnitTestCase{
+			Name:      endpoint.Name,
+			ClassName: "schmokin.endpoints",
+			Time:      endpoint.ResponseTime.Mean * float64(endpoint.ResponseTime.Count) / float64(time.Second),
+			SystemOut: fmt.Sprintf("%v transactions at %.2f/s, p50 %.2fms, p95 %.2fms, p99 %.2fms",
+				endpoint.Transactions,
+				endpoint.TransactionRate,
+				float64(endpoint.ResponseTime.P50)/float64(time.Millisecond),
+				float64(endpoint.ResponseTime.P95)/float64(time.Millisecond),
+				float64(endpoint.ResponseTime.P99)/float64(time.Millisecond)),
+		}
+		if endpoint.FailedTransactions > 0 || endpoint.ErroredTransactions > 0 {
+			testCase.Failure = &JUnitFailure{
+				Message: fmt.Sprintf("%v of %v transactions failed and %v errored",
+					endpoint.FailedTransactions, endpoint.Transactions, endpoint.ErroredTransactions),
+				Type: "TransactionsFailed",
+				Text: fmt.Sprintf("Failure Rate %v%%, Error Rate %v%%",
+					percentage(endpoint.FailedTransactions, endpoint.Transactions),
+					percentage(endpoint.ErroredTransactions, endpoint.Transactions)),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	for _, threshold := range thresholds {
+		testCase := JUnitTestCase{
+			Name:      threshold.Expression,
+			ClassName: "schmokin.thresholds",
+		}
+		if !threshold.Missing {
+			testCase.SystemOut = fmt.Sprintf("%v was %v", threshold.Metric, thresholdValue(threshold))
+		}
+		if !threshold.Passed {
+			message := fmt.Sprintf("%v was %v, expected %v%v%v", threshold.Metric, thresholdValue(threshold), threshold.Operator, threshold.Value, threshold.Unit())
+			if threshold.Missing {
+				message = fmt.Sprintf("there were no transactions to %v", threshold.Endpoint)
+			}
+			testCase.Failure = &JUnitFailure{
+				Message: message,
+				Type:    "ThresholdFailed",
+				Text:    threshold.Expression,
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Tests = len(suite.TestCases)
+	for _, testCase := range suite.TestCases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+	return JUnitTestSuites{TestSuites: []JUnitTestSuite{suite}}
+}
+
+// writeJUnit writes the report as indented XML.
+func writeJUnit(writer io.Writer, report JUnitTestSuites) error {
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+// writeJUnitFile writes the report as indented XML to the file.
+func writeJUnitFile(path string, report JUnitTestSuites) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := writeJUnit(file, report); err != nil {
+		return err
+	}
+	return file.Sync()
+}
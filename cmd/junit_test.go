@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitReport(t *testing.T) {
+	result := createResult()
+	thresholdResults := evaluateThresholds(t, result, "p95<300ms", "POST /orders:p95<300ms", "GET /missing:p95<1s")
+
+	report := junitReport(ReportConfig{URLFile: "urls.txt", Workers: 2}, result, thresholdResults)
+
+	assert.Len(t, report.TestSuites, 1)
+	suite := report.TestSuites[0]
+	assert.Equal(t, "schmokin", suite.Name)
+	assert.Equal(t, 5, suite.Tests)
+	assert.Equal(t, 3, suite.Failures)
+	assert.Equal(t, 2.0, suite.Time)
+	assert.Equal(t, "2020-01-02T03:04:05", suite.Timestamp)
+	assert.Contains(t, suite.Properties, JUnitProperty{Name: "urls", Value: "urls.txt"})
+	assert.Contains(t, suite.Properties, JUnitProperty{Name: "workers", Value: "2"})
+
+	users := suite.TestCases[0]
+	assert.Equal(t, "GET /users", users.Name)
+	assert.Equal(t, "schmokin.endpoints", users.ClassName)
+	assert.InDelta(t, 0.6, users.Time, 0.0001)
+	assert.Nil(t, users.Failure)
+
+	orders := suite.TestCases[1]
+	assert.Equal(t, "POST /orders", orders.Name)
+	assert.InDelta(t, 0.75, orders.Time, 0.0001)
+	assert.Equal(t, "1 of 4 transactions failed and 1 errored", orders.Failure.Message)
+	assert.Equal(t, "Failure Rate 25.00%, Error Rate 25.00%", orders.Failure.Text)
+
+	assert.Equal(t, "p95<300ms", suite.TestCases[2].Name)
+	assert.Equal(t, "schmokin.thresholds", suite.TestCases[2].ClassName)
+	assert.Nil(t, suite.TestCases[2].Failure)
+	assert.Equal(t, "p95 was 400.00ms, expected <300ms", suite.TestCases[3].Failure.Message)
+	assert.Equal(t, "there were no transactions to GET /missing", suite.TestCases[4].Failure.Message)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	result := createResult()
+	report := junitReport(ReportConfig{}, result, evaluateThresholds(t, result, "errored==0"))
+	var buffer bytes.Buffer
+
+	assert.Nil(t, writeJUnit(&buffer, report))
+
+	assert.True(t, strings.HasPrefix(buffer.String(), xml.Header))
+	assert.Contains(t, buffer.String(), `<testcase name="GET /users" classname="schmokin.endpoints" time="0.6">`)
+	assert.Contains(t, buffer.String(), `<failure message="errored was 1, expected ==0" type="ThresholdFailed">errored==0</failure>`)
+	written := JUnitTestSuites{}
+	assert.Nil(t, xml.Unmarshal(buffer.Bytes(), &written))
+	assert.Equal(t, report.TestSuites[0].Tests, written.TestSuites[0].Tests)
+	assert.Equal(t, report.TestSuites[0].Failures, written.TestSuites[0].Failures)
+	assert.Len(t, written.TestSuites[0].TestCases, 3)
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// createResult creates the result of a run of two endpoints, one of which
+// had a failed and an errored transaction.
+func createResult() *service.SchmokinResult {
+	startTime := time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)
+	return &service.SchmokinResult{
+		Transactions:           10,
+		SuccessfulTransactions: 8,
+		FailedTransactions:     1,
+		ErroredTransactions:    1,
+		ElapsedTime:            2 * time.Second,
+		StartTime:              startTime,
+		EndTime:                startTime.Add(2 * time.Second),
+		TransactionRate:        5,
+		ResponseTime:           service.Distribution{Count: 9, Mean: float64(150 * time.Millisecond), P95: int64(250 * time.Millisecond)},
+		Endpoints: []service.EndpointResult{
+			{
+				Name:                   "GET /users",
+				Transactions:           6,
+				SuccessfulTransactions: 6,
+				TransactionRate:        3,
+				ResponseTime:           service.Distribution{Count: 6, Mean: float64(100 * time.Millisecond), P95: int64(120 * time.Millisecond)},
+			},
+			{
+				Name:                   "POST /orders",
+				Transactions:           4,
+				SuccessfulTransactions: 2,
+				FailedTransactions:     1,
+				ErroredTransactions:    1,
+				TransactionRate:        2,
+				ResponseTime:           service.Distribution{Count: 3, Mean: float64(250 * time.Millisecond), P95: int64(400 * time.Millisecond)},
+			},
+		},
+	}
+}
+
+func evaluateThresholds(t *testing.T, result *service.SchmokinResult, expressions ...string) []service.ThresholdResult {
+	thresholds, err := service.ParseThresholds(expressions)
+	assert.Nil(t, err)
+	return service.EvaluateThresholds(thresholds, *result)
+}
+
+func TestThresholdRecords(t *testing.T) {
+	result := createResult()
+	thresholdResults := evaluateThresholds(t, result, "p95<300ms", "GET /users:availability>=100", "errored==0", "GET /missing:p95<1s")
+
+	assert.Equal(t, [][]string{
+		{ThresholdKey, ValueKey, ResultKey},
+		{"p95<300ms", "250.00ms", "pass"},
+		{"GET /users:availability>=100", "100.00%", "pass"},
+		{"errored==0", "1", "fail"},
+		{"GET /missing:p95<1s", "-", "fail"},
+	}, thresholdRecords(thresholdResults))
+}
+
+func TestWriteReport(t *testing.T) {
+	result := createResult()
+	report := Report{
+		SchemaVersion: ReportSchemaVersion,
+		Config:        ReportConfig{URLFile: "urls.txt", Workers: 2, Iterations: 5, Processes: 1},
+		Result:        result,
+		Thresholds:    evaluateThresholds(t, result, "p95<300ms"),
+	}
+	var buffer bytes.Buffer
+
+	assert.Nil(t, writeReport(&buffer, report, false))
+
+	written := Report{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &written))
+	assert.Equal(t, report.Config, written.Config)
+	assert.Equal(t, report.Thresholds, written.Thresholds)
+	assert.Equal(t, result.Transactions, written.Result.Transactions)
+	assert.Equal(t, result.ResponseTime.P95, written.Result.ResponseTime.P95)
+	assert.True(t, result.StartTime.Equal(written.Result.StartTime))
+	assert.Len(t, written.Result.Endpoints, 2)
+}
+
+func TestWriteReportNamesTheFieldsInSnakeCase(t *testing.T) {
+	result := createResult()
+	var buffer bytes.Buffer
+
+	assert.Nil(t, writeReport(&buffer, Report{
+		SchemaVersion: ReportSchemaVersion,
+		Config:        ReportConfig{URLFile: "urls.txt", Duration: time.Minute},
+		Result:        result,
+		Thresholds:    evaluateThresholds(t, result, "GET /users:p95<300ms"),
+	}, false))
+
+	written := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &written))
+	assert.Equal(t, float64(ReportSchemaVersion), written["schema_version"])
+	config := written["config"].(map[string]interface{})
+	assert.Equal(t, "urls.txt", config["url_file"])
+	assert.Equal(t, float64(time.Minute), config["duration_ns"])
+	written = written["result"].(map[string]interface{})
+	assert.Equal(t, float64(2*time.Second), written["elapsed_time_ns"])
+	assert.Equal(t, "2020-01-02T03:04:05Z", written["start_time"])
+	assert.Equal(t, float64(250*time.Millisecond), written["response_time"].(map[string]interface{})["p95_ns"])
+	endpoint := written["endpoints"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "GET /users", endpoint["name"])
+	assert.Equal(t, 3.0, endpoint["transaction_rate_per_second"])
+}
+
+func TestWriteReportWritesOneLinePerRun(t *testing.T) {
+	var buffer bytes.Buffer
+
+	assert.Nil(t, writeReport(&buffer, Report{SchemaVersion: ReportSchemaVersion, Result: createResult()}, true))
+	assert.Nil(t, writeReport(&buffer, Report{SchemaVersion: ReportSchemaVersion, Result: createResult()}, true))
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		report := Report{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &report))
+		assert.Equal(t, ReportSchemaVersion, report.SchemaVersion)
+	}
+}
+
+func TestWriteBreakdowns(t *testing.T) {
+	result := createResult()
+	result.StatusCodes = map[int]int64{200: 8, 500: 1}
+	directory, err := ioutil.TempDir("", "schmokin")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	dir := filepath.Join(directory, "breakdowns")
+
+	assert.Nil(t, writeBreakdowns(dir, result, evaluateThresholds(t, result, "p95<300ms")))
+
+	for name, records := range map[string]int{"endpoints.csv": 3, "status_codes.csv": 3, "thresholds.csv": 2} {
+		file, err := os.Open(filepath.Join(dir, name))
+		assert.Nil(t, err)
+		written, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		assert.Nil(t, err)
+		assert.Len(t, written, records, name)
+	}
+	assert.FileExists(t, filepath.Join(dir, "error_categories.csv"))
+}
+
+func TestWriteBreakdownsWithoutThresholds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schmokin")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, writeBreakdowns(dir, createResult(), nil))
+
+	_, err = os.Stat(filepath.Join(dir, "thresholds.csv"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -16,17 +16,26 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"os/user"
+	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/reaandrew/schmokin/cli"
 	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
+	"github.com/reaandrew/schmokin/service"
 	"github.com/reaandrew/schmokin/utils"
 	"github.com/spf13/cobra"
 
@@ -47,35 +56,425 @@ var (
 	random          bool
 	workerCount     int
 	iterations      int
+	duration        time.Duration
+	stages          []string
+	rate            float64
+	maxWorkers      int
+	poisson         bool
+	warmup          string
+	interval        time.Duration
+	intervalsFile   string
+	breakdownDir    string
+	junitFile       string
+	progress        bool
+	thresholds      []string
+	abortOnFail     bool
 	processes       int
 	output          string
 	server          bool
 	serverHost      string
 	serverPort      int
 	workerEndpoints []string
+	headers         []string
+	timeout         time.Duration
+	tlsOptions      schmokinHTTP.TLSOptions
+	cookieJar       string
+	saveCookies     bool
+	captureSize     int64
 	Timer           utils.Timer      = &utils.DefaultTimer{}
 	Client          schmokinHTTP.Client = schmokinHTTP.NewDefaultClient()
 )
 
 const (
-	TransactionsKey           = "Transactions"
-	AvailabilityKey           = "Availability (%)"
-	ElapsedTimeKey            = "Elapsed Time (ms)"
-	TotalBytesSentKey         = "Total Bytes Sent"
-	TotalBytesReceivedKey     = "Total Bytes Received"
-	AverageResponseTimeKey    = "Average Response Time (ms)"
-	AverageTransactionRateKey = "Average Transaction Rate (requests/sec)"
-	ConcurrencyKey            = "Concurrency"
-	DataSendRateKey           = "Data Send Rate (bytes/sec)"
-	DataReceiveRateKey        = "Data Receive Rate (bytes/sec)"
-	SuccessfulTransactionsKey = "Successful Transactions"
-	FailedTransactionsKey     = "Failed Transactions"
-	LongestTransactionKey     = "Longest Transaction"
-	ShortestTransactionKey    = "Shortest Transaction"
-	WorkerCountKey            = "Worker Count"
-	RandomKey                 = "Random"
+	TransactionsKey              = "Transactions"
+	AvailabilityKey              = "Availability (%)"
+	SuccessRateKey               = "Success Rate (%)"
+	FailureRateKey               = "Failure Rate (%)"
+	ElapsedTimeKey               = "Elapsed Time (ms)"
+	TotalBytesSentKey            = "Total Bytes Sent"
+	TotalBytesReceivedKey        = "Total Bytes Received"
+	AverageResponseTimeKey       = "Average Response Time (ms)"
+	AverageTransactionRateKey    = "Average Transaction Rate (requests/sec)"
+	ConcurrencyKey               = "Concurrency"
+	DataSendRateKey              = "Data Send Rate (bytes/sec)"
+	DataReceiveRateKey           = "Data Receive Rate (bytes/sec)"
+	SuccessfulTransactionsKey    = "Successful Transactions"
+	FailedTransactionsKey        = "Failed Transactions"
+	ErroredTransactionsKey       = "Errored Transactions"
+	TimedOutTransactionsKey      = "Timed Out Transactions"
+	DroppedIterationsKey         = "Dropped Iterations"
+	LateIterationsKey            = "Late Iterations"
+	LongestTransactionKey        = "Longest Transaction"
+	ShortestTransactionKey       = "Shortest Transaction"
+	ResponseTimeStdDevKey        = "Response Time Std Dev (ms)"
+	ResponseTimeP50Key           = "Response Time p50 (ms)"
+	ResponseTimeP75Key           = "Response Time p75 (ms)"
+	ResponseTimeP90Key           = "Response Time p90 (ms)"
+	ResponseTimeP95Key           = "Response Time p95 (ms)"
+	ResponseTimeP99Key           = "Response Time p99 (ms)"
+	ResponseTimeP999Key          = "Response Time p99.9 (ms)"
+	ResponseTimeMaxKey           = "Response Time Max (ms)"
+	CorrectedResponseTimeKey     = "Average Corrected Response Time (ms)"
+	CorrectedResponseTimeP50Key  = "Corrected Response Time p50 (ms)"
+	CorrectedResponseTimeP95Key  = "Corrected Response Time p95 (ms)"
+	CorrectedResponseTimeP99Key  = "Corrected Response Time p99 (ms)"
+	CorrectedResponseTimeP999Key = "Corrected Response Time p99.9 (ms)"
+	CorrectedResponseTimeMaxKey  = "Corrected Response Time Max (ms)"
+	DNSLookupTimeKey             = "Average DNS Lookup Time (ms)"
+	ConnectTimeKey               = "Average Connect Time (ms)"
+	TLSHandshakeTimeKey          = "Average TLS Handshake Time (ms)"
+	FirstByteTimeKey             = "Average Time To First Byte (ms)"
+	ContentTransferTimeKey       = "Average Content Transfer Time (ms)"
+	WorkerCountKey               = "Worker Count"
+	RandomKey                    = "Random"
+	InterruptedKey               = "Interrupted"
+	StageKey                     = "Stage"
+	WarmupKey                    = "Warm-up"
+	WarmupTransactionsKey        = "Warm-up Transactions"
+	WarmupFailedTransactionsKey  = "Warm-up Failed Transactions"
+	WarmupErroredTransactionsKey = "Warm-up Errored Transactions"
+	WarmupElapsedTimeKey         = "Warm-up Elapsed Time (ms)"
+	WarmupAverageResponseTimeKey = "Warm-up Average Response Time (ms)"
+	WarmupResponseTimeP95Key     = "Warm-up Response Time p95 (ms)"
+	EndpointKey                  = "Endpoint"
+	ErrorRateKey                 = "Error Rate (%)"
+	TransactionRateKey           = "Transaction Rate (requests/sec)"
+	P50Key                       = "p50 (ms)"
+	P95Key                       = "p95 (ms)"
+	P99Key                       = "p99 (ms)"
+	StatusCodeKey                = "Status Code"
+	ErrorCategoryKey             = "Error"
+	PercentageKey                = "Percentage (%)"
+	StatusCodesKey               = "Status Codes"
+	ErrorCategoriesKey           = "Errors"
+	IntervalKey                  = "Interval"
+	ActiveUsersKey               = "Active Users"
+	BytesSentKey                 = "Bytes Sent"
+	BytesReceivedKey             = "Bytes Received"
+	ThresholdsKey                = "Thresholds"
+	ThresholdKey                 = "Threshold"
+	ValueKey                     = "Value"
+	ResultKey                    = "Result"
 )
 
+// printStages prints the metrics of the transactions started during each stage.
+func printStages(cmd *cobra.Command, stages []service.StageResult) {
+	for index, stage := range stages {
+		printStage(cmd, fmt.Sprintf("%v %d (%v to %d virtual users)", StageKey, index+1, stage.Duration, stage.Target), stage)
+	}
+}
+
+// endpointRecords returns the results of each endpoint as records under
+// a header record.
+func endpointRecords(endpoints []service.EndpointResult) [][]string {
+	records := [][]string{
+		{EndpointKey, TransactionsKey, ErrorRateKey, FailureRateKey, TransactionRateKey, P50Key, P95Key, P99Key},
+	}
+	for _, endpoint := range endpoints {
+		records = append(records, []string{
+			endpoint.Name,
+			fmt.Sprintf("%v", endpoint.Transactions),
+			fmt.Sprintf("%.2f", endpoint.ErrorRate()*100),
+			fmt.Sprintf("%.2f", endpoint.FailureRate()*100),
+			fmt.Sprintf("%.2f", endpoint.TransactionRate),
+			fmt.Sprintf("%.2f", float64(endpoint.ResponseTime.P50)/(float64(time.Millisecond))),
+			fmt.Sprintf("%.2f", float64(endpoint.ResponseTime.P95)/(float64(time.Millisecond))),
+			fmt.Sprintf("%.2f", float64(endpoint.ResponseTime.P99)/(float64(time.Millisecond))),
+		})
+	}
+	return records
+}
+
+// intervalRecords returns the time series of the metrics of each interval
+// as records under a header record.
+func intervalRecords(intervals []service.Interval) [][]string {
+	records := [][]string{
+		{IntervalKey, WarmupKey, TransactionsKey, FailedTransactionsKey, ErroredTransactionsKey, TransactionRateKey, ActiveUsersKey, BytesSentKey, BytesReceivedKey, P50Key, P95Key, P99Key},
+	}
+	for _, interval := range intervals {
+		records = append(records, []string{
+			interval.StartTime.Format(time.RFC3339Nano),
+			fmt.Sprintf("%v", interval.Warmup),
+			fmt.Sprintf("%v", interval.Transactions),
+			fmt.Sprintf("%v", interval.FailedTransactions),
+			fmt.Sprintf("%v", interval.ErroredTransactions),
+			fmt.Sprintf("%.2f", interval.TransactionRate()),
+			fmt.Sprintf("%v", interval.ActiveUsers),
+			fmt.Sprintf("%v", interval.TotalBytesSent),
+			fmt.Sprintf("%v", interval.TotalBytesReceived),
+			fmt.Sprintf("%.2f", float64(interval.ResponseTime.P50)/(float64(time.Millisecond))),
+			fmt.Sprintf("%.2f", float64(interval.ResponseTime.P95)/(float64(time.Millisecond))),
+			fmt.Sprintf("%.2f", float64(interval.ResponseTime.P99)/(float64(time.Millisecond))),
+		})
+	}
+	return records
+}
+
+// ThresholdsExitCode is the exit code when any threshold fails.
+const ThresholdsExitCode = 99
+
+// ThresholdsError is returned when any threshold fails.
+type ThresholdsError struct {
+	Failed int
+	Total  int
+}
+
+func (err ThresholdsError) Error() string {
+	return fmt.Sprintf("%d of %d thresholds failed", err.Failed, err.Total)
+}
+
+// thresholdValue formats the value of the metric of a threshold in its
+// unit, or a dash when the endpoint of the threshold had no transactions.
+func thresholdValue(result service.ThresholdResult) string {
+	if result.Missing {
+		return "-"
+	}
+	if result.Unit() == "" {
+		return fmt.Sprintf("%v", result.Actual)
+	}
+	return fmt.Sprintf("%.2f%v", result.Actual, result.Unit())
+}
+
+// thresholdRecords returns whether each threshold passed as records under
+// a header record.
+func thresholdRecords(results []service.ThresholdResult) [][]string {
+	records := [][]string{{ThresholdKey, ValueKey, ResultKey}}
+	for _, result := range results {
+		outcome := "fail"
+		if result.Passed {
+			outcome = "pass"
+		}
+		records = append(records, []string{result.Expression, thresholdValue(result), outcome})
+	}
+	return records
+}
+
+// printThresholds prints a table of whether each threshold passed.
+func printThresholds(cmd *cobra.Command, results []service.ThresholdResult) {
+	if len(results) == 0 {
+		return
+	}
+	cmd.Println("")
+	cmd.Println(ThresholdsKey)
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for _, record := range thresholdRecords(results) {
+		fmt.Fprintln(w, strings.Join(record, "\t"))
+	}
+	w.Flush()
+}
+
+// writeIntervals writes the time series to the file as JSON when its
+// name ends in .json and as CSV otherwise.
+func writeIntervals(path string, intervals []service.Interval) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if filepath.Ext(path) == ".json" {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(intervals)
+	}
+	return writeRecords(file, intervalRecords(intervals))
+}
+
+// writeBreakdowns writes the breakdowns of the result by endpoint, status
+// code, error category and threshold as CSV files in the directory, each
+// with its own columns.
+func writeBreakdowns(dir string, result *service.SchmokinResult, thresholdResults []service.ThresholdResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	breakdowns := map[string][][]string{
+		"endpoints.csv":        endpointRecords(result.Endpoints),
+		"status_codes.csv":     statusCodeRecords(result),
+		"error_categories.csv": errorCategoryRecords(result),
+	}
+	if len(thresholdResults) > 0 {
+		breakdowns["thresholds.csv"] = thresholdRecords(thresholdResults)
+	}
+	for name, records := range breakdowns {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		err = writeRecords(file, records)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRecords writes the records to the file as CSV.
+func writeRecords(file *os.File, records [][]string) error {
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// ReportSchemaVersion is the version of the schema of the JSON output. It
+// is raised whenever a field is removed, renamed or changes its meaning,
+// while fields may be added without raising it.
+const ReportSchemaVersion = 1
+
+// ReportConfig holds the options the run was made with.
+type ReportConfig struct {
+	URLFile    string          `json:"url_file"`
+	Workers    int             `json:"workers"`
+	Iterations int             `json:"iterations"`
+	Processes  int             `json:"processes"`
+	Random     bool            `json:"random"`
+	Duration   time.Duration   `json:"duration_ns"`
+	Rate       float64         `json:"rate_per_second"`
+	Stages     []service.Stage `json:"stages"`
+	Interval   time.Duration   `json:"interval_ns"`
+}
+
+// Report is the JSON output of a run, its schema is documented in the
+// README. Times are RFC 3339, durations are in nanoseconds and end in _ns,
+// rates of outcomes are fractions and bytes are not humanized.
+type Report struct {
+	SchemaVersion int                       `json:"schema_version"`
+	Config        ReportConfig              `json:"config"`
+	Result        *service.SchmokinResult   `json:"result"`
+	Thresholds    []service.ThresholdResult `json:"thresholds"`
+}
+
+// machineReadable reports whether the output format is read by programs
+// rather than people.
+func machineReadable(format string) bool {
+	switch format {
+	case "csv", "json", "jsonl", "junit":
+		return true
+	default:
+		return false
+	}
+}
+
+// writeReport writes the report as indented JSON, or on one line to append
+// a line per run when lines is set.
+func writeReport(writer io.Writer, report Report, lines bool) error {
+	encoder := json.NewEncoder(writer)
+	if !lines {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(report)
+}
+
+func percentage(count int64, transactions int) string {
+	if transactions == 0 {
+		return "0.00"
+	}
+	return fmt.Sprintf("%.2f", float64(count)/float64(transactions)*100)
+}
+
+// statusCodeRecords returns the transactions with each status code as
+// records under a header record.
+func statusCodeRecords(result *service.SchmokinResult) [][]string {
+	codes := []int{}
+	for code := range result.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	records := [][]string{{StatusCodeKey, TransactionsKey, PercentageKey}}
+	for _, code := range codes {
+		count := result.StatusCodes[code]
+		records = append(records, []string{fmt.Sprintf("%v", code), fmt.Sprintf("%v", count), percentage(count, result.Transactions)})
+	}
+	return records
+}
+
+// errorCategoryRecords returns the transactions which failed with each
+// kind of error as records under a header record.
+func errorCategoryRecords(result *service.SchmokinResult) [][]string {
+	categories := []string{}
+	for category := range result.ErrorCategories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	records := [][]string{{ErrorCategoryKey, TransactionsKey, PercentageKey}}
+	for _, category := range categories {
+		count := result.ErrorCategories[category]
+		records = append(records, []string{category, fmt.Sprintf("%v", count), percentage(count, result.Transactions)})
+	}
+	return records
+}
+
+// printCounts prints the count and percentage of each record under a title.
+func printCounts(cmd *cobra.Command, title string, records [][]string) {
+	if len(records) < 2 {
+		return
+	}
+	cmd.Println("")
+	cmd.Println(title)
+	for _, record := range records[1:] {
+		cmd.Println(fmt.Sprintf("%v: %v (%v%%)", RightPad2Len(record[0], ".", 45), record[1], record[2]))
+	}
+}
+
+// printEndpoints prints a table of the results of each endpoint.
+func printEndpoints(cmd *cobra.Command, endpoints []service.EndpointResult) {
+	cmd.Println("")
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for _, record := range endpointRecords(endpoints) {
+		fmt.Fprintln(w, strings.Join(record, "\t"))
+	}
+	w.Flush()
+}
+
+// printWarmup prints the metrics of the transactions made during the warm-up.
+func printWarmup(cmd *cobra.Command, warmup service.StageResult) {
+	if warmup.Transactions == 0 {
+		return
+	}
+	printStage(cmd, WarmupKey, warmup)
+}
+
+func printStage(cmd *cobra.Command, title string, stage service.StageResult) {
+	cmd.Println("")
+	cmd.Println(title)
+	cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(TransactionsKey, ".", 45), stage.Transactions))
+	cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(AvailabilityKey, ".", 45), stage.Availability()*100))
+	cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(SuccessRateKey, ".", 45), stage.SuccessRate()*100))
+	cmd.Println(fmt.Sprintf("%v: %.2f", RightPad2Len(AverageTransactionRateKey, ".", 45), stage.TransactionRate()))
+	cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(FailedTransactionsKey, ".", 45), stage.FailedTransactions))
+	cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ErroredTransactionsKey, ".", 45), stage.ErroredTransactions))
+	cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(TimedOutTransactionsKey, ".", 45), stage.TimedOutTransactions))
+	cmd.Println(fmt.Sprintf("%v: %.2f", RightPad2Len(AverageResponseTimeKey, ".", 45), stage.ResponseTime.Mean/(float64(time.Millisecond))))
+	cmd.Println(fmt.Sprintf("%v: %.2f", RightPad2Len(ResponseTimeP50Key, ".", 45), float64(stage.ResponseTime.P50)/(float64(time.Millisecond))))
+	cmd.Println(fmt.Sprintf("%v: %.2f", RightPad2Len(ResponseTimeP95Key, ".", 45), float64(stage.ResponseTime.P95)/(float64(time.Millisecond))))
+	cmd.Println(fmt.Sprintf("%v: %.2f", RightPad2Len(ResponseTimeP99Key, ".", 45), float64(stage.ResponseTime.P99)/(float64(time.Millisecond))))
+}
+
+// handleInterrupts interrupts the run on the first SIGINT or SIGTERM so the
+// partial result is still reported, a second kills the worker processes
+// and exits straight away. The returned function stops the handling.
+func handleInterrupts(cmd *cobra.Command, cancel context.CancelFunc, schmokinClient *cli.SchmokinCLI) func() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-signals; !ok {
+			return
+		}
+		cmd.PrintErrln("Interrupted, waiting for the transactions in flight to finish, interrupt again to exit")
+		cancel()
+		if _, ok := <-signals; !ok {
+			return
+		}
+		schmokinClient.KillWorkerProcesses()
+		os.Exit(130)
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(signals)
+	}
+}
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "schmokin",
@@ -87,7 +486,7 @@ Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cmd.Println(`
+		fmt.Fprintln(cmd.ErrOrStderr(), `
  ____  _   _ ____   ____ _____ 
 / ___|| | | |  _ \ / ___| ____|
 \___ \| | | | |_) | |  _|  _|  
@@ -95,25 +494,82 @@ to quickly create a Cobra application.`,
 |____/ \___/|_| \_\\____|_____|
 		`)
 
+		parsedStages, err := service.ParseStages(stages)
+		if err != nil {
+			return err
+		}
+
+		parsedWarmup, err := service.ParseWarmup(warmup)
+		if err != nil {
+			return err
+		}
+
+		// The thresholds given with --threshold replace those in the config file
+		parsedThresholds, err := service.ParseThresholds(viper.GetStringSlice("thresholds"))
+		if err != nil {
+			return err
+		}
+
+		// A duration or stages without an iteration count run until they end
+		if (duration > 0 || len(parsedStages) > 0) && !cmd.Flags().Changed("number-iterations") {
+			iterations = 0
+		}
+
 		schmokinClient := cli.NewSchmokinCLIBuilder().
 			SetURLFilePath(urlFile).
 			SetRandom(random).
 			SetWorkers(workerCount).
 			SetIterations(iterations).
+			SetDuration(duration).
+			SetStages(parsedStages).
+			SetRate(rate).
+			SetMaxWorkers(maxWorkers).
+			SetPoisson(poisson).
+			SetWarmup(parsedWarmup).
+			SetInterval(interval).
+			SetProgress(progress).
+			SetThresholds(parsedThresholds).
+			SetAbortOnThreshold(abortOnFail).
 			SetServer(server).
 			SetServerHost(serverHost).
 			SetServerPort(serverPort).
 			SetProcesses(processes).
+			SetHeaders(headers).
+			SetTimeout(timeout).
+			SetTLSOptions(tlsOptions).
+			SetCookieJar(cookieJar).
+			SetSaveCookies(saveCookies).
+			SetCaptureSize(captureSize).
 			Build()
 
-		result, err := schmokinClient.Run()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		defer handleInterrupts(cmd, cancel, schmokinClient)()
+
+		result, err := schmokinClient.Run(ctx)
 		if err != nil {
-			panic(err)
+			return err
+		}
+
+		thresholdResults := service.EvaluateThresholds(parsedThresholds, *result)
+		reportConfig := ReportConfig{
+			URLFile:    urlFile,
+			Workers:    workerCount,
+			Iterations: iterations,
+			Processes:  processes,
+			Random:     random,
+			Duration:   duration,
+			Rate:       rate,
+			Stages:     parsedStages,
+			Interval:   interval,
 		}
 
 		transactions := fmt.Sprintf("%v", result.Transactions)
 		availability := fmt.Sprintf("%v", result.Availability*100)
-		elapsedTime := fmt.Sprintf("%v", result.ElapsedTime.String())
+		successRate := fmt.Sprintf("%v", result.SuccessRate*100)
+		failureRate := fmt.Sprintf("%v", result.FailureRate*100)
+		errorRate := fmt.Sprintf("%v", result.ErrorRate*100)
+		elapsedTime := fmt.Sprintf("%.2f", float64(result.ElapsedTime)/(float64(time.Millisecond)))
 		totalBytesSent := fmt.Sprintf("%v", humanize.Bytes(uint64(result.TotalBytesSent)))
 		totalBytesReceived := fmt.Sprintf("%v", humanize.Bytes(uint64(result.TotalBytesReceived)))
 		averageResponseTime := fmt.Sprintf("%.2f", result.AverageResponseTime/(float64(time.Millisecond)))
@@ -123,16 +579,49 @@ to quickly create a Cobra application.`,
 		dataReceiveRate := fmt.Sprintf("%v", humanize.Bytes(uint64(result.DataReceiveRate)))
 		successfulTransactions := fmt.Sprintf("%v", result.SuccessfulTransactions)
 		failedTransactions := fmt.Sprintf("%v", result.FailedTransactions)
+		erroredTransactions := fmt.Sprintf("%v", result.ErroredTransactions)
+		timedOutTransactions := fmt.Sprintf("%v", result.TimedOutTransactions)
+		droppedIterations := fmt.Sprintf("%v", result.DroppedIterations)
+		lateIterations := fmt.Sprintf("%v", result.LateIterations)
 		longestTransaction := time.Duration(result.LongestTransaction).String()
 		shortestTransaction := time.Duration(result.ShortestTransaction).String()
+		responseTimeStdDev := fmt.Sprintf("%.2f", result.ResponseTime.StdDev/(float64(time.Millisecond)))
+		responseTimeP50 := fmt.Sprintf("%.2f", float64(result.ResponseTime.P50)/(float64(time.Millisecond)))
+		responseTimeP75 := fmt.Sprintf("%.2f", float64(result.ResponseTime.P75)/(float64(time.Millisecond)))
+		responseTimeP90 := fmt.Sprintf("%.2f", float64(result.ResponseTime.P90)/(float64(time.Millisecond)))
+		responseTimeP95 := fmt.Sprintf("%.2f", float64(result.ResponseTime.P95)/(float64(time.Millisecond)))
+		responseTimeP99 := fmt.Sprintf("%.2f", float64(result.ResponseTime.P99)/(float64(time.Millisecond)))
+		responseTimeP999 := fmt.Sprintf("%.2f", float64(result.ResponseTime.P999)/(float64(time.Millisecond)))
+		responseTimeMax := fmt.Sprintf("%.2f", float64(result.ResponseTime.Max)/(float64(time.Millisecond)))
+		correctedResponseTime := fmt.Sprintf("%.2f", result.CorrectedResponseTime.Mean/(float64(time.Millisecond)))
+		correctedResponseTimeP50 := fmt.Sprintf("%.2f", float64(result.CorrectedResponseTime.P50)/(float64(time.Millisecond)))
+		correctedResponseTimeP95 := fmt.Sprintf("%.2f", float64(result.CorrectedResponseTime.P95)/(float64(time.Millisecond)))
+		correctedResponseTimeP99 := fmt.Sprintf("%.2f", float64(result.CorrectedResponseTime.P99)/(float64(time.Millisecond)))
+		correctedResponseTimeP999 := fmt.Sprintf("%.2f", float64(result.CorrectedResponseTime.P999)/(float64(time.Millisecond)))
+		correctedResponseTimeMax := fmt.Sprintf("%.2f", float64(result.CorrectedResponseTime.Max)/(float64(time.Millisecond)))
+		dnsLookupTime := fmt.Sprintf("%.2f", result.DNSLookupTime.Mean/(float64(time.Millisecond)))
+		connectTime := fmt.Sprintf("%.2f", result.ConnectTime.Mean/(float64(time.Millisecond)))
+		tlsHandshakeTime := fmt.Sprintf("%.2f", result.TLSHandshakeTime.Mean/(float64(time.Millisecond)))
+		firstByteTime := fmt.Sprintf("%.2f", result.FirstByteTime.Mean/(float64(time.Millisecond)))
+		contentTransferTime := fmt.Sprintf("%.2f", result.ContentTransferTime.Mean/(float64(time.Millisecond)))
 		workerCount := fmt.Sprintf("%v", workerCount)
 		randomEnabled := fmt.Sprintf("%v", random)
+		interrupted := fmt.Sprintf("%v", result.Interrupted)
+		warmupTransactions := fmt.Sprintf("%v", result.Warmup.Transactions)
+		warmupFailedTransactions := fmt.Sprintf("%v", result.Warmup.FailedTransactions)
+		warmupErroredTransactions := fmt.Sprintf("%v", result.Warmup.ErroredTransactions)
+		warmupElapsedTime := fmt.Sprintf("%.2f", float64(result.Warmup.EndTime.Sub(result.Warmup.StartTime))/(float64(time.Millisecond)))
+		warmupAverageResponseTime := fmt.Sprintf("%.2f", result.Warmup.ResponseTime.Mean/(float64(time.Millisecond)))
+		warmupResponseTimeP95 := fmt.Sprintf("%.2f", float64(result.Warmup.ResponseTime.P95)/(float64(time.Millisecond)))
 
 		if err == nil {
 			records := [][]string{
 				{
 					TransactionsKey,
 					AvailabilityKey,
+					SuccessRateKey,
+					FailureRateKey,
+					ErrorRateKey,
 					ElapsedTimeKey,
 					TotalBytesSentKey,
 					TotalBytesReceivedKey,
@@ -143,14 +632,47 @@ to quickly create a Cobra application.`,
 					DataReceiveRateKey,
 					SuccessfulTransactionsKey,
 					FailedTransactionsKey,
+					ErroredTransactionsKey,
+					TimedOutTransactionsKey,
+					DroppedIterationsKey,
+					LateIterationsKey,
 					LongestTransactionKey,
 					ShortestTransactionKey,
+					ResponseTimeStdDevKey,
+					ResponseTimeP50Key,
+					ResponseTimeP75Key,
+					ResponseTimeP90Key,
+					ResponseTimeP95Key,
+					ResponseTimeP99Key,
+					ResponseTimeP999Key,
+					ResponseTimeMaxKey,
+					CorrectedResponseTimeKey,
+					CorrectedResponseTimeP50Key,
+					CorrectedResponseTimeP95Key,
+					CorrectedResponseTimeP99Key,
+					CorrectedResponseTimeP999Key,
+					CorrectedResponseTimeMaxKey,
+					DNSLookupTimeKey,
+					ConnectTimeKey,
+					TLSHandshakeTimeKey,
+					FirstByteTimeKey,
+					ContentTransferTimeKey,
 					WorkerCountKey,
 					RandomKey,
+					InterruptedKey,
+					WarmupTransactionsKey,
+					WarmupFailedTransactionsKey,
+					WarmupErroredTransactionsKey,
+					WarmupElapsedTimeKey,
+					WarmupAverageResponseTimeKey,
+					WarmupResponseTimeP95Key,
 				},
 				{
 					transactions,
 					availability,
+					successRate,
+					failureRate,
+					errorRate,
 					elapsedTime,
 					totalBytesSent,
 					totalBytesReceived,
@@ -161,10 +683,40 @@ to quickly create a Cobra application.`,
 					dataReceiveRate,
 					successfulTransactions,
 					failedTransactions,
+					erroredTransactions,
+					timedOutTransactions,
+					droppedIterations,
+					lateIterations,
 					longestTransaction,
 					shortestTransaction,
+					responseTimeStdDev,
+					responseTimeP50,
+					responseTimeP75,
+					responseTimeP90,
+					responseTimeP95,
+					responseTimeP99,
+					responseTimeP999,
+					responseTimeMax,
+					correctedResponseTime,
+					correctedResponseTimeP50,
+					correctedResponseTimeP95,
+					correctedResponseTimeP99,
+					correctedResponseTimeP999,
+					correctedResponseTimeMax,
+					dnsLookupTime,
+					connectTime,
+					tlsHandshakeTime,
+					firstByteTime,
+					contentTransferTime,
 					workerCount,
 					randomEnabled,
+					interrupted,
+					warmupTransactions,
+					warmupFailedTransactions,
+					warmupErroredTransactions,
+					warmupElapsedTime,
+					warmupAverageResponseTime,
+					warmupResponseTimeP95,
 				},
 			}
 
@@ -200,9 +752,38 @@ to quickly create a Cobra application.`,
 				}
 			}
 			w.Flush()
+			if intervalsFile != "" {
+				if err := writeIntervals(intervalsFile, result.Intervals); err != nil {
+					return err
+				}
+			}
+			if breakdownDir != "" {
+				if err := writeBreakdowns(breakdownDir, result, thresholdResults); err != nil {
+					return err
+				}
+			}
+			if junitFile != "" {
+				if err := writeJUnitFile(junitFile, junitReport(reportConfig, result, thresholdResults)); err != nil {
+					return err
+				}
+			}
 			switch output {
+			case "json", "jsonl":
+				report := Report{
+					SchemaVersion: ReportSchemaVersion,
+					Config:        reportConfig,
+					Result:        result,
+					Thresholds:    thresholdResults,
+				}
+				if err := writeReport(cmd.OutOrStdout(), report, output == "jsonl"); err != nil {
+					return err
+				}
+			case "junit":
+				if err := writeJUnit(cmd.OutOrStdout(), junitReport(reportConfig, result, thresholdResults)); err != nil {
+					return err
+				}
 			case "csv":
-				w := csv.NewWriter(os.Stdout)
+				w := csv.NewWriter(cmd.OutOrStdout())
 
 				for _, record := range records {
 					if err := w.Write(record); err != nil {
@@ -214,6 +795,9 @@ to quickly create a Cobra application.`,
 				cmd.Println("")
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(TransactionsKey, ".", 45), transactions))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(AvailabilityKey, ".", 45), availability))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(SuccessRateKey, ".", 45), successRate))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(FailureRateKey, ".", 45), failureRate))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ErrorRateKey, ".", 45), errorRate))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ElapsedTimeKey, ".", 45), elapsedTime))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(TotalBytesSentKey, ".", 45), totalBytesSent))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(TotalBytesReceivedKey, ".", 45), totalBytesReceived))
@@ -224,10 +808,51 @@ to quickly create a Cobra application.`,
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(DataReceiveRateKey, ".", 45), dataReceiveRate))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(SuccessfulTransactionsKey, ".", 45), successfulTransactions))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(FailedTransactionsKey, ".", 45), failedTransactions))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ErroredTransactionsKey, ".", 45), erroredTransactions))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(TimedOutTransactionsKey, ".", 45), timedOutTransactions))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(DroppedIterationsKey, ".", 45), droppedIterations))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(LateIterationsKey, ".", 45), lateIterations))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(LongestTransactionKey, ".", 45), longestTransaction))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ShortestTransactionKey, ".", 45), shortestTransaction))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeStdDevKey, ".", 45), responseTimeStdDev))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeP50Key, ".", 45), responseTimeP50))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeP75Key, ".", 45), responseTimeP75))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeP90Key, ".", 45), responseTimeP90))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeP95Key, ".", 45), responseTimeP95))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeP99Key, ".", 45), responseTimeP99))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeP999Key, ".", 45), responseTimeP999))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ResponseTimeMaxKey, ".", 45), responseTimeMax))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(CorrectedResponseTimeKey, ".", 45), correctedResponseTime))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(CorrectedResponseTimeP50Key, ".", 45), correctedResponseTimeP50))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(CorrectedResponseTimeP95Key, ".", 45), correctedResponseTimeP95))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(CorrectedResponseTimeP99Key, ".", 45), correctedResponseTimeP99))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(CorrectedResponseTimeP999Key, ".", 45), correctedResponseTimeP999))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(CorrectedResponseTimeMaxKey, ".", 45), correctedResponseTimeMax))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(DNSLookupTimeKey, ".", 45), dnsLookupTime))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ConnectTimeKey, ".", 45), connectTime))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(TLSHandshakeTimeKey, ".", 45), tlsHandshakeTime))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(FirstByteTimeKey, ".", 45), firstByteTime))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(ContentTransferTimeKey, ".", 45), contentTransferTime))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(WorkerCountKey, ".", 45), workerCount))
 				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(RandomKey, ".", 45), randomEnabled))
+				cmd.Println(fmt.Sprintf("%v: %v", RightPad2Len(InterruptedKey, ".", 45), interrupted))
+				printCounts(cmd, StatusCodesKey, statusCodeRecords(result))
+				printCounts(cmd, ErrorCategoriesKey, errorCategoryRecords(result))
+				printEndpoints(cmd, result.Endpoints)
+				printWarmup(cmd, result.Warmup)
+				printStages(cmd, result.Stages)
+				printThresholds(cmd, thresholdResults)
+			}
+			failed := 0
+			for _, thresholdResult := range thresholdResults {
+				if !thresholdResult.Passed {
+					failed++
+				}
+			}
+			if failed > 0 {
+				// The thresholds are the reason for failing rather than the usage
+				cmd.SilenceUsage = true
+				return ThresholdsError{Failed: failed, Total: len(thresholdResults)}
 			}
 		}
 		return err
@@ -238,6 +863,9 @@ to quickly create a Cobra application.`,
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
+		if _, ok := err.(ThresholdsError); ok {
+			os.Exit(ThresholdsExitCode)
+		}
 		os.Exit(1)
 	}
 }
@@ -251,11 +879,35 @@ func init() {
 
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.schmokin.yaml)")
 	RootCmd.PersistentFlags().StringVarP(&urlFile, "urls", "u", "", "The urls file to use")
-	RootCmd.PersistentFlags().StringVarP(&output, "output", "o", "default", "The output format to use for the results")
+	RootCmd.PersistentFlags().StringVarP(&output, "output", "o", "default", "The output format to use for the results, one of default, csv, json, jsonl for one line of JSON per run or junit")
 	RootCmd.PersistentFlags().BoolVarP(&random, "random", "r", false, "Read the urls in random order")
 	RootCmd.PersistentFlags().IntVarP(&workerCount, "worker-count", "c", 1, "The number of concurrent virtual users")
 	RootCmd.PersistentFlags().IntVarP(&iterations, "number-iterations", "n", 1, "The number of iterations per virtual user")
+	RootCmd.PersistentFlags().DurationVar(&duration, "duration", 0, "How long the virtual users loop over the urls for e.g. 30m, with -n each stops at whichever comes first")
+	RootCmd.PersistentFlags().StringArrayVar(&stages, "stage", []string{}, "A stage ramping the virtual users across every process to a target over a duration e.g. 30s:10, repeat for each stage")
+	RootCmd.PersistentFlags().Float64Var(&rate, "rate", 0, "Start iterations at this many per second across every process instead of as fast as the virtual users can, with stages the rate ramps from this to each stage target")
+	RootCmd.PersistentFlags().IntVar(&maxWorkers, "max-workers", 100, "The most virtual users started across every process to keep up with the rate")
+	RootCmd.PersistentFlags().BoolVar(&poisson, "poisson", false, "Space the iterations started at the rate as a Poisson process")
+	RootCmd.PersistentFlags().StringVar(&warmup, "warmup", "", "Warm up before the run for a duration e.g. 1m or a number of transactions across every process e.g. 100 with the virtual users, rate or stages of the run, the warm-up is reported apart from the run")
+	RootCmd.PersistentFlags().DurationVar(&interval, "interval", service.DefaultInterval, "How long each interval of the time series of the metrics lasts, 0 for no time series")
+	RootCmd.PersistentFlags().StringVar(&junitFile, "junit-file", "", "Write a JUnit XML report with a test case for each endpoint and threshold to this file")
+	RootCmd.PersistentFlags().StringVar(&intervalsFile, "intervals-file", "", "Write the time series of the metrics to this file, as JSON when it ends in .json and as CSV otherwise")
+	RootCmd.PersistentFlags().StringVar(&breakdownDir, "breakdown-dir", "", "Write the result broken down by endpoint, status code, error category and threshold to CSV files in this directory")
+	RootCmd.PersistentFlags().BoolVar(&progress, "progress", true, "Show the progress while running, refreshed every second on a terminal and logged every 10 seconds otherwise")
+	RootCmd.PersistentFlags().StringArrayVar(&thresholds, "threshold", []string{}, "A threshold the result has to meet e.g. p95<300ms, availability>=99.9 or rps>500, prefix with an endpoint name and a colon for one endpoint e.g. \"GET /users:p95<300ms\", repeat for each threshold or list them under thresholds in the config file")
+	RootCmd.PersistentFlags().BoolVar(&abortOnFail, "abort-on-threshold", false, "Interrupt the run as soon as a threshold which cannot pass again fails, such as max<1s or errored<10")
 	RootCmd.PersistentFlags().IntVarP(&processes, "processes", "p", 1, "The number of processes to run virtual users")
+	RootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Second, "The maximum time allowed for each transaction, 0 for no limit")
+	RootCmd.PersistentFlags().BoolVarP(&tlsOptions.Insecure, "insecure", "k", false, "Skip verification of server certificates")
+	RootCmd.PersistentFlags().StringVar(&tlsOptions.CACert, "cacert", "", "The CA certificates to verify servers with")
+	RootCmd.PersistentFlags().StringVar(&tlsOptions.Cert, "cert", "", "The client certificate to send")
+	RootCmd.PersistentFlags().StringVar(&tlsOptions.Key, "key", "", "The private key of the client certificate")
+	RootCmd.PersistentFlags().StringVar(&tlsOptions.MinVersion, "tls-min-version", "", "The minimum TLS version, one of 1.0, 1.1, 1.2 or 1.3")
+	RootCmd.PersistentFlags().StringVar(&tlsOptions.ServerName, "sni", "", "The server name to send and verify instead of the URL host")
+	RootCmd.PersistentFlags().StringVar(&cookieJar, "cookie-jar", "", "A Netscape format cookie file to preload into the cookie jar of every virtual user")
+	RootCmd.PersistentFlags().BoolVar(&saveCookies, "save-cookies", false, "Save the cookie jar of every virtual user to <cookie-jar>.<process>.<user> after the run")
+	RootCmd.PersistentFlags().Int64Var(&captureSize, "capture-size", 0, "The number of bytes of each response body to keep, the rest is read and discarded")
+	RootCmd.PersistentFlags().StringArrayVarP(&headers, "header", "H", []string{}, "A header to send with every request e.g. \"Accept: application/json\"")
 
 	RootCmd.PersistentFlags().BoolVar(&server, "server", false, "Set in server mode")
 	RootCmd.PersistentFlags().IntVar(&serverPort, "server-port", 51234, "The port thew server should bind to")
@@ -285,9 +937,15 @@ func initConfig() {
 	}
 
 	viper.AutomaticEnv() // read in environment variables that match
+	viper.BindFlagValue("thresholds", stringArrayFlag{RootCmd.PersistentFlags().Lookup("threshold")})
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		// Only the results are written to stdout when programs read them
+		writer := RootCmd.OutOrStdout()
+		if machineReadable(output) {
+			writer = RootCmd.ErrOrStderr()
+		}
+		fmt.Fprintln(writer, "Using config file:", viper.ConfigFileUsed())
 	}
 }
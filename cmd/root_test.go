@@ -2,29 +2,67 @@ package cmd_test
 
 import (
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/reaandrew/schmokin/cmd"
+	"github.com/reaandrew/schmokin/service"
 	"github.com/reaandrew/schmokin/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
+// TestMain runs the test binary as a worker process when the command under
+// test starts one, as the worker processes are started from the executable.
+func TestMain(m *testing.M) {
+	for _, arg := range os.Args[1:] {
+		if arg == "--server" {
+			cmd.Execute()
+			os.Exit(0)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// resetFlags puts every flag back to its default, the tests share the flags
+// of the root command so otherwise those set by one would leak into the next.
+func resetFlags(root *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if value, ok := flag.Value.(pflag.SliceValue); ok {
+			value.Replace([]string{})
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	root.PersistentFlags().VisitAll(reset)
+	root.Flags().VisitAll(reset)
+}
+
 func executeCommand(root *cobra.Command, args ...string) (output string, err error) {
 	_, output, err = executeCommandC(root, args...)
 	return output, err
 }
 
 func executeCommandC(root *cobra.Command, args ...string) (c *cobra.Command, output string, err error) {
-	buf := new(bytes.Buffer)
-	root.SetOut(buf)
-	root.SetErr(buf)
+	resetFlags(root)
+	viper.Reset()
+	stdout := new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(new(bytes.Buffer))
 	root.SetArgs(args)
 	c, err = root.ExecuteC()
 
-	return c, buf.String(), err
+	return c, stdout.String(), err
 }
 
 func TestOutput(t *testing.T) {
@@ -38,13 +76,24 @@ func TestOutput(t *testing.T) {
 
 	patterns := []string{
 		`Random[^\s]+\s[\w]+`,
+		`Interrupted[^\s]+\s(true|false)`,
+		`(Status Codes|Errors)\n[^\s]+[^\n]*: \d+ \([\d\.]+%\)`,
+		`Endpoint\s+Transactions\s+Error Rate \(%\)\s+Failure Rate \(%\)\s+Transaction Rate \(requests/sec\)\s+p50 \(ms\)\s+p95 \(ms\)\s+p99 \(ms\)`,
+		`POST /1\s+\d+\s+[\d\.]+\s+[\d\.]+\s+[\d\.]+\s+[\d\.]+\s+[\d\.]+\s+[\d\.]+`,
 		`Worker Count[^\s]+\s[\d]+`,
 		`Successful Transactions[^\s]+\s[\d]+`,
 		`Failed Transactions[^\s]+\s[\d]+`,
+		`Errored Transactions[^\s]+\s[\d]+`,
+		`Success Rate \(%\)[^\s]+\s[\d]+`,
+		`Failure Rate \(%\)[^\s]+\s[\d]+`,
+		`Error Rate \(%\)[^\s]+\s[\d]+`,
+		`Timed Out Transactions[^\s]+\s[\d]+`,
+		`Dropped Iterations[^\s]+\s[\d]+`,
+		`Late Iterations[^\s]+\s[\d]+`,
 		`Concurrency[^\s]+\s[\d\.]+`,
 		`Shortest Transaction[^\s]+\s[\d]+s`,
 		`Longest Transaction[^\s]+\s[\d]+s`,
-		`Elapsed Time \(ms\)[^\s]+\s[\d]+s`,
+		`Elapsed Time \(ms\)[^\s]+\s[\d\.]+`,
 		`Availability \(%\)[^\s]+\s[\d]+`,
 		`Transactions[^\s]+\s[\d]+`,
 		`Data Receive Rate \(bytes/sec\)[^\s]+\s[\d]+ B`,
@@ -53,6 +102,25 @@ func TestOutput(t *testing.T) {
 		`Total Bytes Received[^\s]+\s[\d]+ B`,
 		`Average Transaction Rate \(requests/sec\)[^\s]+\s[^0][\d\.]+`,
 		`Average Response Time \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time Std Dev \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time p50 \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time p75 \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time p90 \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time p95 \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time p99 \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time p99\.9 \(ms\)[^\s]+\s[\d\.]+`,
+		`Response Time Max \(ms\)[^\s]+\s[\d\.]+`,
+		`Average Corrected Response Time \(ms\)[^\s]+\s[\d\.]+`,
+		`Corrected Response Time p50 \(ms\)[^\s]+\s[\d\.]+`,
+		`Corrected Response Time p95 \(ms\)[^\s]+\s[\d\.]+`,
+		`Corrected Response Time p99 \(ms\)[^\s]+\s[\d\.]+`,
+		`Corrected Response Time p99\.9 \(ms\)[^\s]+\s[\d\.]+`,
+		`Corrected Response Time Max \(ms\)[^\s]+\s[\d\.]+`,
+		`Average DNS Lookup Time \(ms\)[^\s]+\s[\d\.]+`,
+		`Average Connect Time \(ms\)[^\s]+\s[\d\.]+`,
+		`Average TLS Handshake Time \(ms\)[^\s]+\s[\d\.]+`,
+		`Average Time To First Byte \(ms\)[^\s]+\s[\d\.]+`,
+		`Average Content Transfer Time \(ms\)[^\s]+\s[\d\.]+`,
 	}
 
 	for _, pattern := range patterns {
@@ -61,3 +129,168 @@ func TestOutput(t *testing.T) {
 		assert.True(t, matched, pattern)
 	}
 }
+
+func TestIntervalsFile(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+	directory, err := ioutil.TempDir("", "schmokin")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	intervalsFile := filepath.Join(directory, "intervals.json")
+
+	_, err = executeCommand(cmd.RootCmd, "-u", file.Name(), "-n", "1", "-c", "1", "--intervals-file", intervalsFile)
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(intervalsFile)
+	assert.Nil(t, err)
+	intervals := []service.Interval{}
+	assert.Nil(t, json.Unmarshal(data, &intervals))
+	assert.NotEmpty(t, intervals)
+	transactions := 0
+	for _, interval := range intervals {
+		assert.Equal(t, service.DefaultInterval, interval.Duration)
+		transactions += interval.Transactions
+	}
+	assert.Equal(t, 1, transactions)
+}
+
+func TestJSONOutput(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+
+	output, err := executeCommand(cmd.RootCmd, "-u", file.Name(), "-n", "2", "-c", "1", "-o", "json")
+	assert.Nil(t, err)
+
+	report := cmd.Report{}
+	assert.Nil(t, json.Unmarshal([]byte(output), &report))
+	assert.Equal(t, cmd.ReportSchemaVersion, report.SchemaVersion)
+	assert.Equal(t, file.Name(), report.Config.URLFile)
+	assert.Equal(t, 1, report.Config.Workers)
+	assert.Equal(t, 2, report.Config.Iterations)
+	assert.Equal(t, 2, report.Result.Transactions)
+	assert.False(t, report.Result.StartTime.IsZero())
+	assert.True(t, report.Result.EndTime.After(report.Result.StartTime))
+}
+
+func TestJSONOutputWithConfigFile(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+	directory, err := ioutil.TempDir("", "schmokin")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	config := filepath.Join(directory, "schmokin.yaml")
+	assert.Nil(t, ioutil.WriteFile(config, []byte("timeout: 30s\n"), 0644))
+
+	output, err := executeCommand(cmd.RootCmd, "--config", config, "-u", file.Name(), "-n", "1", "-c", "1", "-o", "json")
+	assert.Nil(t, err)
+
+	report := cmd.Report{}
+	assert.Nil(t, json.Unmarshal([]byte(output), &report))
+	assert.Equal(t, 1, report.Result.Transactions)
+}
+
+func TestCSVOutput(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+	dir, err := ioutil.TempDir("", "schmokin")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	output, err := executeCommand(cmd.RootCmd, "-u", file.Name(), "-n", "1", "-c", "1", "-o", "csv", "--breakdown-dir", dir)
+	assert.Nil(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, cmd.TransactionsKey, records[0][0])
+	assert.Equal(t, "1", records[1][0])
+	assert.FileExists(t, filepath.Join(dir, "endpoints.csv"))
+}
+
+func TestCSVOutputWithWarmup(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+	dir, err := ioutil.TempDir("", "schmokin")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	output, err := executeCommand(cmd.RootCmd, "-u", file.Name(), "-n", "1", "-c", "1", "-o", "csv", "--warmup", "3", "--breakdown-dir", dir)
+	assert.Nil(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	for index, key := range records[0] {
+		if key == cmd.WarmupTransactionsKey {
+			assert.Equal(t, "3", records[1][index])
+			return
+		}
+	}
+	t.Errorf("there is no %v column", cmd.WarmupTransactionsKey)
+}
+
+func TestJUnitOutput(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+
+	output, err := executeCommand(cmd.RootCmd, "-u", file.Name(), "-n", "1", "-c", "1", "-o", "junit")
+	assert.Nil(t, err)
+
+	assert.True(t, strings.HasPrefix(output, xml.Header))
+	report := cmd.JUnitTestSuites{}
+	assert.Nil(t, xml.Unmarshal([]byte(output), &report))
+	assert.Len(t, report.TestSuites, 1)
+	suite := report.TestSuites[0]
+	assert.Equal(t, 1, suite.Tests)
+	assert.Len(t, suite.TestCases, 1)
+	assert.Equal(t, "schmokin.endpoints", suite.TestCases[0].ClassName)
+}
+
+func TestThresholds(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+
+	output, err := executeCommand(cmd.RootCmd, "-u", file.Name(), "-n", "1", "-c", "1", "--threshold", "transactions>=1", "--threshold", "transactions>5")
+
+	assert.Equal(t, cmd.ThresholdsError{Failed: 1, Total: 2}, err)
+	assert.Regexp(t, `transactions>=1\s+1\s+pass`, output)
+	assert.Regexp(t, `transactions>5\s+1\s+fail`, output)
+}
+
+func TestThresholdsFromConfigFile(t *testing.T) {
+	file := utils.CreateTestFile([]string{
+		"http://localhost:8080/1",
+	})
+	defer os.Remove(file.Name())
+	directory, err := ioutil.TempDir("", "schmokin")
+	assert.Nil(t, err)
+	defer os.RemoveAll(directory)
+	config := filepath.Join(directory, "schmokin.yaml")
+	assert.Nil(t, ioutil.WriteFile(config, []byte("thresholds:\n  - transactions>=1\n  - \"POST /1:transactions>5\"\n"), 0644))
+
+	output, err := executeCommand(cmd.RootCmd, "--config", config, "-u", file.Name(), "-n", "1", "-c", "1")
+
+	assert.Equal(t, cmd.ThresholdsError{Failed: 1, Total: 2}, err)
+	assert.Regexp(t, `transactions>=1\s+1\s+pass`, output)
+	assert.Regexp(t, `POST /1:transactions>5\s+1\s+fail`, output)
+
+	output, err = executeCommand(cmd.RootCmd, "--config", config, "-u", file.Name(), "-n", "1", "-c", "1", "--threshold", "transactions==1")
+
+	assert.Nil(t, err)
+	assert.Regexp(t, `transactions==1\s+1\s+pass`, output)
+	assert.NotContains(t, output, "transactions>5")
+}
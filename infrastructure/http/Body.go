@@ -0,0 +1,148 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	contentTypeHeader = "Content-Type"
+	acceptHeader      = "Accept"
+	formContentType   = "application/x-www-form-urlencoded"
+	jsonContentType   = "application/json"
+	fileContentType   = "application/octet-stream"
+)
+
+// Body holds the curl style body options given on a line. The content is
+// built on every call to Build so each transaction sends a fresh body.
+type Body struct {
+	Data       []string
+	DataBinary []string
+	JSON       []string
+	Form       []string
+}
+
+// Empty returns true when no body options were supplied.
+func (body Body) Empty() bool {
+	return len(body.Data) == 0 && len(body.DataBinary) == 0 && len(body.JSON) == 0 && len(body.Form) == 0
+}
+
+// Build returns the content of the body and the Content-Type which should
+// be sent with it unless the line sets its own.
+func (body Body) Build() (content []byte, contentType string, err error) {
+	if body.Empty() {
+		return
+	}
+	if len(body.Form) > 0 {
+		if len(body.Data) > 0 || len(body.DataBinary) > 0 || len(body.JSON) > 0 {
+			err = errors.New("form fields cannot be combined with data")
+			return
+		}
+		return buildForm(body.Form)
+	}
+
+	var values []string
+	for _, value := range body.Data {
+		data, err := readData(value, true)
+		if err != nil {
+			return nil, "", err
+		}
+		values = append(values, data)
+	}
+	for _, value := range body.DataBinary {
+		data, err := readData(value, false)
+		if err != nil {
+			return nil, "", err
+		}
+		values = append(values, data)
+	}
+	contentType = formContentType
+	content = []byte(strings.Join(values, "&"))
+	if len(body.JSON) > 0 {
+		// Like curl, json values are concatenated rather than joined with &
+		for _, value := range body.JSON {
+			data, err := readData(value, false)
+			if err != nil {
+				return nil, "", err
+			}
+			content = append(content, data...)
+		}
+		contentType = jsonContentType
+	}
+	return
+}
+
+// readData returns the value or, when it starts with @, the contents of the
+// named file. Line breaks are stripped from files when strip is true.
+func readData(value string, strip bool) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	data, err := ioutil.ReadFile(value[1:])
+	if err != nil {
+		return "", err
+	}
+	if strip {
+		return strings.NewReplacer("\r", "", "\n", "").Replace(string(data)), nil
+	}
+	return string(data), nil
+}
+
+// buildForm writes the form fields as multipart/form-data. Fields take the
+// form name=value, name=@file to upload a file (optionally followed by
+// ;type=mime/type) and name=<file to send the contents of a file as the value.
+func buildForm(fields []string) ([]byte, string, error) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	for _, field := range fields {
+		index := strings.Index(field, "=")
+		if index < 1 {
+			return nil, "", fmt.Errorf("invalid form field %q: expected name=value", field)
+		}
+		name, value := field[:index], field[index+1:]
+		switch {
+		case strings.HasPrefix(value, "@"):
+			path, fileType := value[1:], fileContentType
+			if typeIndex := strings.Index(path, ";type="); typeIndex >= 0 {
+				path, fileType = path[:typeIndex], path[typeIndex+len(";type="):]
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, "", err
+			}
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition",
+				fmt.Sprintf(`form-data; name=%q; filename=%q`, name, filepath.Base(path)))
+			header.Set(contentTypeHeader, fileType)
+			part, err := writer.CreatePart(header)
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := part.Write(data); err != nil {
+				return nil, "", err
+			}
+		case strings.HasPrefix(value, "<"):
+			data, err := ioutil.ReadFile(value[1:])
+			if err != nil {
+				return nil, "", err
+			}
+			if err := writer.WriteField(name, string(data)); err != nil {
+				return nil, "", err
+			}
+		default:
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buffer.Bytes(), writer.FormDataContentType(), nil
+}
@@ -1,10 +1,14 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"errors"
-	"fmt"
+	"io"
+	"net"
 	"net/http"
-	"net/http/httputil"
+	"net/http/httptrace"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -13,69 +17,228 @@ import (
 )
 
 type Result struct {
-	TotalBytesSent     int
-	TotalBytesReceived int
-	Error              error
-	ResponseTime       time.Duration
+	Name                  string
+	StatusCode            int
+	ErrorCategory         string
+	TotalBytesSent        int
+	TotalBytesReceived    int
+	Error                 error
+	TimedOut              bool
+	ResponseTime          time.Duration
+	CorrectedResponseTime time.Duration
+	DNSTime               time.Duration
+	ConnectTime           time.Duration
+	TLSTime               time.Duration
+	FirstByteTime         time.Duration
+	ContentTransferTime   time.Duration
+	Body                  []byte
+}
+
+// IsError reports whether the transaction got no response, or failed while
+// reading it, rather than getting a response which was unsuccessful.
+func (result Result) IsError() bool {
+	return result.Error != nil && result.ErrorCategory != ""
+}
+
+// IsFailure reports whether the transaction got a response which was
+// deemed unsuccessful, such as one with an error status code.
+func (result Result) IsFailure() bool {
+	return result.Error != nil && result.ErrorCategory == ""
 }
 
 type Command struct {
-	Client Client
-	Timer  utils.Timer
-	verb   string
+	Context        context.Context
+	Client         Client
+	Timer          utils.Timer
+	Headers        []string
+	Timeout        time.Duration
+	TLS            TLSOptions
+	Jar            http.CookieJar
+	CaptureSize    int64
+	Scheduled      time.Time
+	verb           string
+	header         http.Header
+	body           Body
+	maxTime        time.Duration
+	connectTimeout time.Duration
+	tls            TLSOptions
+	cookies        []*http.Cookie
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// endpointName names the endpoint of a request after its method and URL
+// path, when the line does not name it.
+func endpointName(verb string, rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return verb + " " + rawURL
+	}
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return verb + " " + path
+}
+
+// requestContext returns the context for a request which enforces the timeouts.
+// Requests are cancelled along with the Context of the command when one is set.
+func (httpCommand Command) requestContext() (context.Context, context.CancelFunc) {
+	parent := httpCommand.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	if httpCommand.maxTime > 0 {
+		// Both contexts are released, cancelling the timeout alone would
+		// leave the first registered with the parent until it ends
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, httpCommand.maxTime)
+		cancelRequest := cancel
+		cancel = func() {
+			cancelTimeout()
+			cancelRequest()
+		}
+	}
+	if httpCommand.connectTimeout > 0 {
+		ctx = WithConnectTimeout(ctx, httpCommand.connectTimeout)
+	}
+	ctx = WithTLSOptions(ctx, httpCommand.tls)
+	if httpCommand.Jar != nil {
+		ctx = WithCookieJar(ctx, httpCommand.Jar)
+	}
+	return ctx, cancel
 }
 
 func (httpCommand Command) run(args []string) (result Result) {
 	var verb = httpCommand.verb
 
-	request, err := http.NewRequest(verb, args[0], nil)
+	content, contentType, err := httpCommand.body.Build()
 	if err != nil {
 		result.Error = err
 		return
 	}
-	// When using the TRACE utility for HTTP with golang
-	// we can still use the Timer interface
+	var body io.Reader
+	if content != nil {
+		body = bytes.NewReader(content)
+	}
 
-	// Start the timer
-	timer := httpCommand.Timer.Start()
-	response, err := httpCommand.Client.Execute(request)
+	ctx, cancel := httpCommand.requestContext()
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, verb, args[0], body)
 	if err != nil {
 		result.Error = err
 		return
 	}
-	requestBytes, err := httputil.DumpRequestOut(request, true)
+	ApplyHeader(request, httpCommand.header)
+	for _, cookie := range httpCommand.cookies {
+		request.AddCookie(cookie)
+	}
+	if contentType != "" && request.Header.Get(contentTypeHeader) == "" {
+		request.Header.Set(contentTypeHeader, contentType)
+	}
+	if len(httpCommand.body.JSON) > 0 && request.Header.Get(acceptHeader) == "" {
+		request.Header.Set(acceptHeader, jsonContentType)
+	}
+	// The phases are traced with the wall clock while the Timer
+	// still measures the transaction as a whole
+	phases := &phaseTimer{}
+	meter := &WireMeter{}
+	trace := phases.trace()
+	trace.GotConn = func(info httptrace.GotConnInfo) {
+		meter.attach(info.Conn)
+	}
+	request = request.WithContext(httptrace.WithClientTrace(ctx, trace))
+
+	// The response time runs from the start of the request until
+	// the last byte of the body has been read, the corrected response
+	// time also includes any delay since the request was scheduled
+	var delay time.Duration
+	if !httpCommand.Scheduled.IsZero() {
+		if delay = time.Since(httpCommand.Scheduled); delay < 0 {
+			delay = 0
+		}
+	}
+	timer := httpCommand.Timer.Start()
+	response, err := httpCommand.Client.Execute(request)
 	if err != nil {
 		result.Error = err
+		result.TimedOut = isTimeout(err)
+		result.ErrorCategory = ErrorCategory(err)
+		meter.record(&result)
 		return
 	}
-	result.TotalBytesSent = len(requestBytes)
+	result.StatusCode = response.StatusCode
+	capture := &captureBuffer{limit: httpCommand.CaptureSize}
+	if response.Body != nil {
+		_, err = io.Copy(capture, response.Body)
+		response.Body.Close()
+	}
+	result.ResponseTime = timer.Stop()
+	result.CorrectedResponseTime = delay + result.ResponseTime
+	result.Body = capture.Bytes()
+	phases.record(&result)
+	meter.record(&result)
 	if err != nil {
 		result.Error = err
-	} else if response != nil {
-		if response.Body != nil {
-			defer response.Body.Close()
-		}
-		responseBytes, err := httputil.DumpResponse(response, true)
-		if err != nil {
-			result.Error = err
-		}
-		result.TotalBytesReceived = len(responseBytes)
-		if err != nil {
-			result.Error = err
-		}
-		if response.StatusCode >= 400 {
-			result.Error = errors.New("Error " + strconv.Itoa(response.StatusCode))
-		}
+		result.TimedOut = isTimeout(err)
+		result.ErrorCategory = ErrorCategory(err)
+	} else if response.StatusCode >= 400 {
+		result.Error = errors.New("Error " + strconv.Itoa(response.StatusCode))
 	}
-	// Stop the timer
-	result.ResponseTime = timer.Stop()
 	return
 }
 
+// captureBuffer keeps the first limit bytes written to it
+// and discards the rest.
+type captureBuffer struct {
+	buffer bytes.Buffer
+	limit  int64
+}
+
+func (capture *captureBuffer) Write(p []byte) (int, error) {
+	if remaining := capture.limit - int64(capture.buffer.Len()); remaining > 0 {
+		if int64(len(p)) > remaining {
+			capture.buffer.Write(p[:remaining])
+		} else {
+			capture.buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (capture *captureBuffer) Bytes() []byte {
+	return capture.buffer.Bytes()
+}
+
+// ExecuteLine splits a line of the URL file into its arguments
+// and executes them.
+func (httpCommand Command) ExecuteLine(line string) Result {
+	args, err := utils.SplitArgs(line)
+	if err != nil {
+		return Result{Name: line, Error: err, ErrorCategory: ErrorOther}
+	}
+	if len(args) == 0 {
+		return Result{Name: line, Error: errors.New("no URL supplied"), ErrorCategory: ErrorOther}
+	}
+	return httpCommand.Execute(args)
+}
+
 func (httpCommand Command) Execute(args []string) Result {
 	var result Result
+	// Lines which cannot be parsed are named after their URL
+	name := args[0]
 
 	app := &cli.App{
+		// Header values and bodies may legitimately contain commas
+		DisableSliceFlagSeparator: true,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:        "verb",
@@ -89,14 +252,113 @@ func (httpCommand Command) Execute(args []string) Result {
 				Usage:   "header",
 				Aliases: []string{"H"},
 			},
+			&cli.StringSliceFlag{
+				Name:    "data",
+				Usage:   "data to send as the body, or @file",
+				Aliases: []string{"d"},
+			},
+			&cli.StringSliceFlag{
+				Name:  "data-binary",
+				Usage: "data to send as the body unmodified, or @file",
+			},
+			&cli.StringSliceFlag{
+				Name:  "json",
+				Usage: "json to send as the body, or @file",
+			},
+			&cli.StringSliceFlag{
+				Name:    "form",
+				Usage:   "multipart form field name=value, name=@file or name=<file",
+				Aliases: []string{"F"},
+			},
+			&cli.DurationFlag{
+				Name:  "max-time",
+				Usage: "the maximum time allowed for the transaction",
+			},
+			&cli.DurationFlag{
+				Name:  "connect-timeout",
+				Usage: "the maximum time allowed to connect",
+			},
+			&cli.BoolFlag{
+				Name:    "insecure",
+				Usage:   "skip verification of the server certificate",
+				Aliases: []string{"k"},
+			},
+			&cli.StringFlag{
+				Name:  "cacert",
+				Usage: "the CA certificates to verify the server with",
+			},
+			&cli.StringFlag{
+				Name:  "cert",
+				Usage: "the client certificate",
+			},
+			&cli.StringFlag{
+				Name:  "key",
+				Usage: "the private key of the client certificate",
+			},
+			&cli.StringFlag{
+				Name:  "tls-min-version",
+				Usage: "the minimum TLS version, one of 1.0, 1.1, 1.2 or 1.3",
+			},
+			&cli.StringFlag{
+				Name:  "sni",
+				Usage: "the server name to send and verify instead of the URL host",
+			},
+			&cli.StringSliceFlag{
+				Name:    "cookie",
+				Usage:   "cookies to send as name=value",
+				Aliases: []string{"b"},
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "the name to report the endpoint as, the method and URL path by default",
+			},
 		},
 		Action: func(c *cli.Context) error {
-			fmt.Println(c.StringSlice("header"))
+			name = endpointName(httpCommand.verb, args[0])
+			if c.IsSet("name") {
+				name = c.String("name")
+			}
+			header, err := BuildHeader(httpCommand.Headers, c.StringSlice("header"))
+			if err != nil {
+				return err
+			}
+			httpCommand.header = header
+			cookies, err := ParseCookies(c.StringSlice("cookie"))
+			if err != nil {
+				return err
+			}
+			httpCommand.cookies = cookies
+			httpCommand.body = Body{
+				Data:       c.StringSlice("data"),
+				DataBinary: c.StringSlice("data-binary"),
+				JSON:       c.StringSlice("json"),
+				Form:       c.StringSlice("form"),
+			}
+			httpCommand.maxTime = httpCommand.Timeout
+			if c.IsSet("max-time") {
+				httpCommand.maxTime = c.Duration("max-time")
+			}
+			httpCommand.connectTimeout = c.Duration("connect-timeout")
+			httpCommand.tls = httpCommand.TLS.Merge(TLSOptions{
+				Insecure:   c.Bool("insecure"),
+				CACert:     c.String("cacert"),
+				Cert:       c.String("cert"),
+				Key:        c.String("key"),
+				MinVersion: c.String("tls-min-version"),
+				ServerName: c.String("sni"),
+			})
 			result = httpCommand.run(args)
 			return nil
 		},
 	}
-	app.Run(args)
+	if err := app.Run(args); err != nil {
+		result.Error = err
+	}
+	result.Name = name
+	// Lines which fail before a request is sent have no status code
+	if result.Error != nil && result.StatusCode == 0 && result.ErrorCategory == "" {
+		result.ErrorCategory = ErrorOther
+	}
 
 	return result
 }
@@ -0,0 +1,386 @@
+package http_test
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
+	"github.com/reaandrew/schmokin/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func executeCommand(command schmokinHTTP.Command, args ...string) (*schmokinHTTP.FakeClient, schmokinHTTP.Result) {
+	httpClient := schmokinHTTP.NewFakeClient()
+	command.Client = httpClient
+	command.Timer = utils.NewFakeTimer(0)
+	result := command.Execute(args)
+	return httpClient, result
+}
+
+func Test_CommandSendsHeaders(t *testing.T) {
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"-H", "Accept:application/json",
+		"-H", "X-Value:1",
+		"-H", "X-Value:2")
+
+	assert.Nil(t, result.Error)
+	request := httpClient.Requests[0]
+	assert.Equal(t, "application/json", request.Header.Get("Accept"))
+	assert.Equal(t, []string{"1", "2"}, request.Header["X-Value"])
+}
+
+func Test_CommandOverridesHost(t *testing.T) {
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"-H", "Host:example.com")
+
+	assert.Nil(t, result.Error)
+	assert.Equal(t, "example.com", httpClient.Requests[0].Host)
+}
+
+func Test_CommandLineHeadersOverrideGlobalHeaders(t *testing.T) {
+	command := schmokinHTTP.Command{
+		Headers: []string{"Accept: text/html", "X-Global: true"},
+	}
+	httpClient, result := executeCommand(command,
+		"http://localhost:8080/1",
+		"-H", "Accept:application/json")
+
+	assert.Nil(t, result.Error)
+	request := httpClient.Requests[0]
+	assert.Equal(t, []string{"application/json"}, request.Header["Accept"])
+	assert.Equal(t, "true", request.Header.Get("X-Global"))
+}
+
+func Test_CommandReturnsErrorForInvalidHeader(t *testing.T) {
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"-H", "Invalid Header")
+
+	assert.NotNil(t, result.Error)
+	assert.Empty(t, httpClient.Requests)
+}
+
+func readBody(t *testing.T, request *http.Request) string {
+	body, err := ioutil.ReadAll(request.Body)
+	assert.Nil(t, err)
+	return string(body)
+}
+
+func Test_CommandSendsData(t *testing.T) {
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"-d", "a=1",
+		"-d", "b=2,3")
+
+	assert.Nil(t, result.Error)
+	request := httpClient.Requests[0]
+	assert.Equal(t, "a=1&b=2,3", readBody(t, request))
+	assert.Equal(t, int64(9), request.ContentLength)
+	assert.Equal(t, "application/x-www-form-urlencoded", request.Header.Get("Content-Type"))
+}
+
+func Test_CommandSendsDataBinaryFromFile(t *testing.T) {
+	file := utils.CreateTestFile([]string{"line 1", "line 2"})
+	defer os.Remove(file.Name())
+
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"--data-binary", "@"+file.Name(),
+		"-H", "Content-Type:text/plain")
+
+	assert.Nil(t, result.Error)
+	request := httpClient.Requests[0]
+	assert.Equal(t, "line 1\nline 2", readBody(t, request))
+	assert.Equal(t, "text/plain", request.Header.Get("Content-Type"))
+}
+
+func Test_CommandSendsJSON(t *testing.T) {
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"--json", `{"a":1}`)
+
+	assert.Nil(t, result.Error)
+	request := httpClient.Requests[0]
+	assert.Equal(t, `{"a":1}`, readBody(t, request))
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	assert.Equal(t, "application/json", request.Header.Get("Accept"))
+}
+
+func Test_CommandSendsMultipartForm(t *testing.T) {
+	file := utils.CreateTestFile([]string{"contents"})
+	defer os.Remove(file.Name())
+
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"-F", "name=value",
+		"-F", "upload=@"+file.Name()+";type=text/plain")
+
+	assert.Nil(t, result.Error)
+	request := httpClient.Requests[0]
+	assert.Nil(t, request.ParseMultipartForm(1024))
+	assert.Equal(t, "value", request.FormValue("name"))
+	upload, header, err := request.FormFile("upload")
+	assert.Nil(t, err)
+	contents, _ := ioutil.ReadAll(upload)
+	assert.Equal(t, "contents", string(contents))
+	assert.Equal(t, "text/plain", header.Header.Get("Content-Type"))
+}
+
+func Test_CommandSendsAFreshBodyForEachTransaction(t *testing.T) {
+	httpClient := schmokinHTTP.NewFakeClient()
+	command := schmokinHTTP.Command{
+		Client: httpClient,
+		Timer:  utils.NewFakeTimer(0),
+	}
+	for i := 0; i < 2; i++ {
+		result := command.Execute([]string{"http://localhost:8080/1", "-d", "a=1"})
+		assert.Nil(t, result.Error)
+	}
+
+	for _, request := range httpClient.Requests {
+		assert.Equal(t, "a=1", readBody(t, request))
+	}
+}
+
+func Test_CommandCorrectsTheResponseTimeForTheDelaySinceItWasScheduled(t *testing.T) {
+	command := schmokinHTTP.Command{
+		Client:    schmokinHTTP.NewFakeClient(),
+		Timer:     utils.NewFakeTimer(100 * time.Millisecond),
+		Scheduled: time.Now().Add(-time.Second),
+	}
+	result := command.Execute([]string{"http://localhost:8080/1"})
+
+	assert.Equal(t, 100*time.Millisecond, result.ResponseTime)
+	assert.InDelta(t, float64(1100*time.Millisecond), float64(result.CorrectedResponseTime), float64(50*time.Millisecond))
+}
+
+func Test_CommandDoesNotCorrectUnscheduledResponseTimes(t *testing.T) {
+	command := schmokinHTTP.Command{
+		Client: schmokinHTTP.NewFakeClient(),
+		Timer:  utils.NewFakeTimer(100 * time.Millisecond),
+	}
+	result := command.Execute([]string{"http://localhost:8080/1"})
+
+	assert.Equal(t, result.ResponseTime, result.CorrectedResponseTime)
+}
+
+func Test_CommandExecutesAQuotedLine(t *testing.T) {
+	httpClient := schmokinHTTP.NewFakeClient()
+	command := schmokinHTTP.Command{
+		Client: httpClient,
+		Timer:  utils.NewFakeTimer(0),
+	}
+
+	result := command.ExecuteLine(`http://localhost:8080/1 -H "Content-Type: application/json" --data-binary '{"a": "b c"}'`)
+
+	assert.Nil(t, result.Error)
+	request := httpClient.Requests[0]
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	assert.Equal(t, `{"a": "b c"}`, readBody(t, request))
+}
+
+func Test_CommandNamesTheEndpointAfterTheMethodAndPath(t *testing.T) {
+	_, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/users/1?expand=true",
+		"-X", "GET")
+
+	assert.Equal(t, "GET /users/1", result.Name)
+}
+
+func Test_CommandNamesTheEndpointWithTheNameFlag(t *testing.T) {
+	_, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/users/1",
+		"-X", "GET",
+		"--name", "get user")
+
+	assert.Equal(t, "get user", result.Name)
+}
+
+func Test_CommandTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	command := schmokinHTTP.Command{
+		Client:  schmokinHTTP.NewDefaultClient(),
+		Timer:   utils.NewDefaultTimer(),
+		Timeout: time.Minute,
+	}
+
+	result := command.Execute([]string{server.URL, "--max-time", "10ms"})
+
+	assert.NotNil(t, result.Error)
+	assert.True(t, result.TimedOut)
+}
+
+func Test_CommandDoesNotReportRefusedConnectionsAsTimeouts(t *testing.T) {
+	command := schmokinHTTP.Command{
+		Client:  schmokinHTTP.NewDefaultClient(),
+		Timer:   utils.NewDefaultTimer(),
+		Timeout: time.Minute,
+	}
+
+	result := command.Execute([]string{"http://localhost:45000"})
+
+	assert.NotNil(t, result.Error)
+	assert.False(t, result.TimedOut)
+}
+
+func Test_CommandVerifiesServerCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	caFile := utils.CreateTestFile([]string{string(certificate)})
+	defer os.Remove(caFile.Name())
+	command := schmokinHTTP.Command{
+		Client: schmokinHTTP.NewDefaultClient(),
+		Timer:  utils.NewDefaultTimer(),
+	}
+
+	assert.NotNil(t, command.Execute([]string{server.URL, "-X", "GET"}).Error)
+	assert.Nil(t, command.Execute([]string{server.URL, "-X", "GET", "-k"}).Error)
+	assert.Nil(t, command.Execute([]string{server.URL, "-X", "GET", "--cacert", caFile.Name()}).Error)
+
+	command.TLS = schmokinHTTP.TLSOptions{Insecure: true}
+	assert.Nil(t, command.Execute([]string{server.URL, "-X", "GET"}).Error)
+}
+
+func Test_CommandReturnsErrorForInvalidTLSOptions(t *testing.T) {
+	command := schmokinHTTP.Command{
+		Client: schmokinHTTP.NewDefaultClient(),
+		Timer:  utils.NewDefaultTimer(),
+	}
+
+	result := command.Execute([]string{"https://localhost:45000", "--tls-min-version", "2.0"})
+
+	assert.NotNil(t, result.Error)
+}
+
+func Test_CommandSendsCookies(t *testing.T) {
+	httpClient, result := executeCommand(schmokinHTTP.Command{},
+		"http://localhost:8080/1",
+		"-b", "a=1; b=2",
+		"-b", "c=3")
+
+	assert.Nil(t, result.Error)
+	assert.Equal(t, "a=1; b=2; c=3", httpClient.Requests[0].Header.Get("Cookie"))
+}
+
+func Test_CommandStoresCookiesInTheJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			return
+		}
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	command := schmokinHTTP.Command{
+		Client: schmokinHTTP.NewDefaultClient(),
+		Timer:  utils.NewDefaultTimer(),
+		Jar:    schmokinHTTP.NewCookieJar(),
+	}
+
+	assert.NotNil(t, command.Execute([]string{server.URL + "/me", "-X", "GET"}).Error)
+	assert.Nil(t, command.Execute([]string{server.URL + "/login", "-X", "GET"}).Error)
+	assert.Nil(t, command.Execute([]string{server.URL + "/me", "-X", "GET"}).Error)
+}
+
+func Test_CommandRecordsPhaseTimings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+	command := schmokinHTTP.Command{
+		Client: schmokinHTTP.NewDefaultClient(),
+		Timer:  utils.NewDefaultTimer(),
+		TLS:    schmokinHTTP.TLSOptions{Insecure: true},
+	}
+
+	result := command.Execute([]string{strings.Replace(server.URL, "127.0.0.1", "localhost", 1), "-X", "GET"})
+
+	assert.Nil(t, result.Error)
+	assert.True(t, result.DNSTime > 0)
+	assert.True(t, result.ConnectTime > 0)
+	assert.True(t, result.TLSTime > 0)
+	assert.True(t, result.FirstByteTime >= 10*time.Millisecond)
+	assert.True(t, result.ContentTransferTime >= 10*time.Millisecond)
+}
+
+func Test_CommandCapturesTheStartOfTheBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world"))
+	}))
+	defer server.Close()
+	command := schmokinHTTP.Command{
+		Client:      schmokinHTTP.NewDefaultClient(),
+		Timer:       utils.NewDefaultTimer(),
+		CaptureSize: 5,
+	}
+
+	result := command.Execute([]string{server.URL, "-X", "GET"})
+
+	assert.Nil(t, result.Error)
+	assert.Equal(t, "hello", string(result.Body))
+	assert.True(t, result.TotalBytesReceived > len("hello world"))
+}
+
+func Test_CommandCountsTLSBytesOnTheWire(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	command := schmokinHTTP.Command{
+		Client: schmokinHTTP.NewDefaultClient(),
+		Timer:  utils.NewDefaultTimer(),
+		TLS:    schmokinHTTP.TLSOptions{Insecure: true},
+	}
+
+	first := command.Execute([]string{server.URL, "-X", "GET"})
+	second := command.Execute([]string{server.URL, "-X", "GET"})
+
+	assert.Nil(t, first.Error)
+	assert.Nil(t, second.Error)
+	// Only the first transaction pays for the handshake
+	assert.True(t, first.TotalBytesSent > second.TotalBytesSent)
+	assert.True(t, first.TotalBytesReceived > second.TotalBytesReceived)
+	assert.True(t, second.TotalBytesReceived > 0)
+}
+
+func Test_CommandCountsHTTP2BytesOnTheWire(t *testing.T) {
+	protocols := make(chan int, 2)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protocols <- r.ProtoMajor
+	}))
+	server.TLS = &tls.Config{NextProtos: []string{"h2"}}
+	server.StartTLS()
+	defer server.Close()
+	command := schmokinHTTP.Command{
+		Client: schmokinHTTP.NewDefaultClient(),
+		Timer:  utils.NewDefaultTimer(),
+		TLS:    schmokinHTTP.TLSOptions{Insecure: true},
+	}
+
+	first := command.Execute([]string{server.URL, "-X", "GET"})
+	second := command.Execute([]string{server.URL, "-X", "GET"})
+
+	assert.Nil(t, first.Error)
+	assert.Nil(t, second.Error)
+	assert.Equal(t, 2, <-protocols)
+	assert.Equal(t, 2, <-protocols)
+	assert.True(t, first.TotalBytesSent > second.TotalBytesSent)
+	assert.True(t, second.TotalBytesSent > 0)
+	assert.True(t, second.TotalBytesReceived > 0)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type contextKey int
+
+const (
+	connectTimeoutKey contextKey = iota
+	tlsOptionsKey
+	cookieJarKey
+)
+
+// WithConnectTimeout returns a context which limits how long the
+// DefaultClient waits to establish a connection for a request.
+func WithConnectTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, connectTimeoutKey, timeout)
+}
+
+func connectTimeout(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(connectTimeoutKey).(time.Duration)
+	return timeout, ok && timeout > 0
+}
+
+// WithTLSOptions returns a context which selects the TLS options the
+// DefaultClient uses for a request.
+func WithTLSOptions(ctx context.Context, options TLSOptions) context.Context {
+	return context.WithValue(ctx, tlsOptionsKey, options)
+}
+
+func tlsOptions(ctx context.Context) TLSOptions {
+	options, _ := ctx.Value(tlsOptionsKey).(TLSOptions)
+	return options
+}
+
+// WithCookieJar returns a context which selects the cookie jar used
+// to send and store the cookies of a request.
+func WithCookieJar(ctx context.Context, jar http.CookieJar) context.Context {
+	return context.WithValue(ctx, cookieJarKey, jar)
+}
+
+func cookieJar(ctx context.Context) http.CookieJar {
+	jar, _ := ctx.Value(cookieJarKey).(http.CookieJar)
+	return jar
+}
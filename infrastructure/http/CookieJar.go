@@ -0,0 +1,177 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const httpOnlyPrefix = "#HttpOnly_"
+
+// CookieJar is a http.CookieJar which also remembers the cookies it has
+// been given so they can be saved in the Netscape cookie file format used
+// by curl and wget.
+type CookieJar struct {
+	jar     *cookiejar.Jar
+	lock    sync.Mutex
+	cookies map[string]*http.Cookie
+}
+
+func NewCookieJar() *CookieJar {
+	jar, _ := cookiejar.New(nil)
+	return &CookieJar{
+		jar:     jar,
+		cookies: map[string]*http.Cookie{},
+	}
+}
+
+func (jar *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	jar.jar.SetCookies(u, cookies)
+	jar.lock.Lock()
+	defer jar.lock.Unlock()
+	for _, cookie := range cookies {
+		saved := *cookie
+		if saved.Domain == "" {
+			saved.Domain = u.Hostname()
+		} else if !strings.HasPrefix(saved.Domain, ".") {
+			saved.Domain = "." + saved.Domain
+		}
+		if saved.Path == "" {
+			saved.Path = "/"
+		}
+		if saved.MaxAge > 0 {
+			saved.Expires = time.Now().Add(time.Duration(saved.MaxAge) * time.Second)
+		}
+		key := strings.Join([]string{saved.Domain, saved.Path, saved.Name}, ";")
+		if saved.MaxAge < 0 || (!saved.Expires.IsZero() && saved.Expires.Before(time.Now())) {
+			delete(jar.cookies, key)
+			continue
+		}
+		jar.cookies[key] = &saved
+	}
+}
+
+func (jar *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return jar.jar.Cookies(u)
+}
+
+// Load adds the cookies read from a Netscape cookie file.
+func (jar *CookieJar) Load(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("cookie file line %d: expected 7 tab separated fields", number)
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("cookie file line %d: invalid expiry %q", number, fields[4])
+		}
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   fields[3] == "TRUE",
+			HttpOnly: httpOnly,
+		}
+		if fields[1] == "TRUE" {
+			cookie.Domain = fields[0]
+		}
+		if expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{
+			Scheme: scheme,
+			Host:   strings.TrimPrefix(fields[0], "."),
+			Path:   cookie.Path,
+		}, []*http.Cookie{cookie})
+	}
+	return scanner.Err()
+}
+
+// Save writes the cookies in the Netscape cookie file format.
+func (jar *CookieJar) Save(writer io.Writer) error {
+	jar.lock.Lock()
+	defer jar.lock.Unlock()
+	keys := make([]string, 0, len(jar.cookies))
+	for key := range jar.cookies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if _, err := io.WriteString(writer, "# Netscape HTTP Cookie File\n"); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		cookie := jar.cookies[key]
+		domain := cookie.Domain
+		if cookie.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expires int64
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		}
+		_, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			netscapeBool(strings.HasPrefix(cookie.Domain, ".")),
+			cookie.Path,
+			netscapeBool(cookie.Secure),
+			expires,
+			cookie.Name,
+			cookie.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func netscapeBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// ParseCookies parses curl style name=value cookies, several of which
+// may be separated by semicolons.
+func ParseCookies(values []string) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	for _, value := range values {
+		for _, pair := range strings.Split(value, ";") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			index := strings.Index(pair, "=")
+			if index < 1 {
+				return nil, fmt.Errorf("invalid cookie %q: expected name=value", pair)
+			}
+			cookies = append(cookies, &http.Cookie{
+				Name:  pair[:index],
+				Value: pair[index+1:],
+			})
+		}
+	}
+	return cookies, nil
+}
@@ -0,0 +1,53 @@
+package http_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+
+	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
+	"github.com/stretchr/testify/assert"
+)
+
+const cookieFile = `# Netscape HTTP Cookie File
+.example.com	TRUE	/	FALSE	0	session	abc
+#HttpOnly_api.example.com	FALSE	/v1	TRUE	4102444800	token	xyz
+`
+
+func Test_CookieJarLoadsCookieFiles(t *testing.T) {
+	jar := schmokinHTTP.NewCookieJar()
+
+	err := jar.Load(bytes.NewBufferString(cookieFile))
+
+	assert.Nil(t, err)
+	cookies := jar.Cookies(&url.URL{Scheme: "https", Host: "api.example.com", Path: "/v1/users"})
+	assert.Len(t, cookies, 2)
+	cookies = jar.Cookies(&url.URL{Scheme: "http", Host: "www.example.com", Path: "/"})
+	assert.Equal(t, []*http.Cookie{{Name: "session", Value: "abc"}}, cookies)
+}
+
+func Test_CookieJarSavesCookieFiles(t *testing.T) {
+	jar := schmokinHTTP.NewCookieJar()
+	assert.Nil(t, jar.Load(bytes.NewBufferString(cookieFile)))
+	jar.SetCookies(&url.URL{Scheme: "http", Host: "example.com"}, []*http.Cookie{
+		{Name: "added", Value: "1"},
+		{Name: "session", Value: "", Domain: "example.com", MaxAge: -1},
+	})
+
+	var buffer bytes.Buffer
+	assert.Nil(t, jar.Save(&buffer))
+
+	assert.Equal(t, `# Netscape HTTP Cookie File
+#HttpOnly_api.example.com	FALSE	/v1	TRUE	4102444800	token	xyz
+example.com	FALSE	/	FALSE	0	added	1
+`, buffer.String())
+}
+
+func Test_CookieJarReturnsErrorForInvalidCookieFiles(t *testing.T) {
+	jar := schmokinHTTP.NewCookieJar()
+
+	err := jar.Load(bytes.NewBufferString("example.com\tTRUE\t/\n"))
+
+	assert.NotNil(t, err)
+}
@@ -1,17 +1,56 @@
 package http
 
-import "net/http"
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
 
 type DefaultClient struct {
-	client http.Client
+	client     http.Client
+	transports *transportPool
 }
 
 func NewDefaultClient() DefaultClient {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	dialContext := func(ctx context.Context, network, address string) (net.Conn, error) {
+		if timeout, ok := connectTimeout(ctx); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return newCountingConn(conn), nil
+	}
 	return DefaultClient{
 		client: http.Client{},
+		transports: &transportPool{
+			transports: map[TLSOptions]*http.Transport{},
+			create: func(config *tls.Config) *http.Transport {
+				transport := http.DefaultTransport.(*http.Transport).Clone()
+				transport.DialContext = dialContext
+				transport.TLSClientConfig = config
+				return transport
+			},
+		},
 	}
 }
 
 func (httpClient DefaultClient) Execute(request *http.Request) (*http.Response, error) {
-	return httpClient.client.Do(request)
+	transport, err := httpClient.transports.get(tlsOptions(request.Context()))
+	if err != nil {
+		return nil, err
+	}
+	client := httpClient.client
+	client.Transport = transport
+	client.Jar = cookieJar(request.Context())
+	return client.Do(request)
 }
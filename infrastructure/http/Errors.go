@@ -0,0 +1,67 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// The categories transport errors are counted in, error responses from
+// the server are counted by their status code instead.
+const (
+	ErrorDNS               = "DNS"
+	ErrorConnectionRefused = "Connection Refused"
+	ErrorConnectionReset   = "Connection Reset"
+	ErrorTLS               = "TLS"
+	ErrorTimeout           = "Timeout"
+	ErrorEOF               = "EOF"
+	ErrorOther             = "Other"
+)
+
+// isTLSAlert reports whether the error is an alert the server sent, such
+// as when it refuses the client certificate, which crypto/tls returns as a
+// remote error wrapping its alert.
+func isTLSAlert(err error) bool {
+	var opError *net.OpError
+	return errors.As(err, &opError) && opError.Op == "remote error" &&
+		strings.HasPrefix(opError.Err.Error(), "tls: ")
+}
+
+func isTLSError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var certificateInvalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var systemRoots x509.SystemRootsError
+	var constraintViolation x509.ConstraintViolationError
+	var insecureAlgorithm x509.InsecureAlgorithmError
+	var recordHeader tls.RecordHeaderError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &certificateInvalid) ||
+		errors.As(err, &hostname) || errors.As(err, &systemRoots) ||
+		errors.As(err, &constraintViolation) || errors.As(err, &insecureAlgorithm) ||
+		errors.As(err, &recordHeader) || isTLSAlert(err)
+}
+
+// ErrorCategory returns the category of a transport error.
+func ErrorCategory(err error) string {
+	var dnsError *net.DNSError
+	switch {
+	case errors.As(err, &dnsError):
+		return ErrorDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE):
+		return ErrorConnectionReset
+	case isTLSError(err):
+		return ErrorTLS
+	case isTimeout(err):
+		return ErrorTimeout
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorEOF
+	default:
+		return ErrorOther
+	}
+}
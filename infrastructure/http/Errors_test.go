@@ -0,0 +1,97 @@
+package http_test
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
+	"github.com/reaandrew/schmokin/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// createClosingServer creates a server which closes each connection once
+// the request has been read, resetting it rather than closing it cleanly
+// when reset is set.
+func createClosingServer(reset bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		if reset {
+			conn.(*net.TCPConn).SetLinger(0)
+		}
+		conn.Close()
+	}))
+}
+
+// closedServerURL returns the URL of a server which has already been
+// closed so connections to it are refused.
+func closedServerURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+func executeLine(line string) schmokinHTTP.Result {
+	command := schmokinHTTP.Command{
+		Client:  schmokinHTTP.NewDefaultClient(),
+		Timer:   utils.NewDefaultTimer(),
+		Timeout: time.Minute,
+	}
+	return command.ExecuteLine(line)
+}
+
+func Test_CommandReturnsTheStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	result := executeLine(server.URL + " -X GET")
+
+	assert.NotNil(t, result.Error)
+	assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+	assert.Equal(t, "", result.ErrorCategory)
+}
+
+func Test_CommandCategorisesTransportErrors(t *testing.T) {
+	closing := createClosingServer(false)
+	defer closing.Close()
+	resetting := createClosingServer(true)
+	defer resetting.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer secure.Close()
+	// The server sends an alert as the client has no certificate for it
+	mutual := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mutual.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	mutual.StartTLS()
+	defer mutual.Close()
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+	closed := closedServerURL()
+
+	cases := map[string]string{
+		closed + " -X GET":                      schmokinHTTP.ErrorConnectionRefused,
+		closing.URL + " -X GET":                 schmokinHTTP.ErrorEOF,
+		resetting.URL + " -X GET":               schmokinHTTP.ErrorConnectionReset,
+		secure.URL + " -X GET":                  schmokinHTTP.ErrorTLS,
+		mutual.URL + " -X GET --insecure":       schmokinHTTP.ErrorTLS,
+		hanging.URL + " -X GET --max-time 10ms": schmokinHTTP.ErrorTimeout,
+		"http://schmokin.invalid -X GET":        schmokinHTTP.ErrorDNS,
+		closed + " -H Invalid":                  schmokinHTTP.ErrorOther,
+	}
+
+	for line, category := range cases {
+		result := executeLine(line)
+		assert.NotNil(t, result.Error, line)
+		assert.Equal(t, 0, result.StatusCode, line)
+		assert.Equal(t, category, result.ErrorCategory, "%v: %v", line, result.Error)
+	}
+}
@@ -24,9 +24,20 @@ func (fakeClient *FakeClient) Execute(request *http.Request) (*http.Response, er
 	m.Lock()
 	fakeClient.Requests = append(fakeClient.Requests, request)
 	m.Unlock()
+	jar := cookieJar(request.Context())
+	if jar != nil {
+		for _, cookie := range jar.Cookies(request.URL) {
+			request.AddCookie(cookie)
+		}
+	}
 	response := &http.Response{
 		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Request:    request,
 	}
 	fakeClient.Interceptor(response)
+	if jar != nil {
+		jar.SetCookies(request.URL, response.Cookies())
+	}
 	return response, nil
 }
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const hostHeader = "Host"
+
+// ParseHeader splits a curl style header e.g. "Content-Type: application/json"
+// into its name and value.
+func ParseHeader(value string) (name string, headerValue string, err error) {
+	index := strings.Index(value, ":")
+	if index < 0 {
+		err = fmt.Errorf("invalid header %q: expected \"Name: Value\"", value)
+		return
+	}
+	name = strings.TrimSpace(value[:index])
+	headerValue = strings.TrimSpace(value[index+1:])
+	if !validHeaderName(name) {
+		err = fmt.Errorf("invalid header %q: invalid name %q", value, name)
+		return
+	}
+	if strings.ContainsAny(headerValue, "\r\n") {
+		err = fmt.Errorf("invalid header %q: value contains a line break", value)
+	}
+	return
+}
+
+// BuildHeader combines the global headers with the headers supplied on a
+// single line. A header named on the line replaces every global value with
+// the same name, while repeating a header on the same level adds a value.
+func BuildHeader(global []string, line []string) (http.Header, error) {
+	header := http.Header{}
+	lineHeader, err := parseHeaders(line)
+	if err != nil {
+		return nil, err
+	}
+	globalHeader, err := parseHeaders(global)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range globalHeader {
+		if _, ok := lineHeader[name]; !ok {
+			header[name] = values
+		}
+	}
+	for name, values := range lineHeader {
+		header[name] = values
+	}
+	return header, nil
+}
+
+// ApplyHeader sets the headers on the request, using the Host header
+// to override the host sent to the server.
+func ApplyHeader(request *http.Request, header http.Header) {
+	for name, values := range header {
+		if name == hostHeader {
+			request.Host = values[len(values)-1]
+			continue
+		}
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+}
+
+func parseHeaders(values []string) (http.Header, error) {
+	header := http.Header{}
+	for _, value := range values {
+		name, headerValue, err := ParseHeader(value)
+		if err != nil {
+			return nil, err
+		}
+		header.Add(name, headerValue)
+	}
+	return header, nil
+}
+
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 127 || !isTokenChar(byte(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTokenChar reports whether c is a valid RFC 7230 token character
+func isTokenChar(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSOptions configures how connections to https endpoints are secured.
+type TLSOptions struct {
+	Insecure   bool
+	CACert     string
+	Cert       string
+	Key        string
+	MinVersion string
+	ServerName string
+}
+
+// Merge returns the options with any option set on line taking precedence.
+func (options TLSOptions) Merge(line TLSOptions) TLSOptions {
+	options.Insecure = options.Insecure || line.Insecure
+	if line.CACert != "" {
+		options.CACert = line.CACert
+	}
+	if line.Cert != "" {
+		options.Cert = line.Cert
+		options.Key = line.Key
+	}
+	if line.MinVersion != "" {
+		options.MinVersion = line.MinVersion
+	}
+	if line.ServerName != "" {
+		options.ServerName = line.ServerName
+	}
+	return options
+}
+
+// Config builds the tls.Config for the options, loading any certificates.
+func (options TLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: options.Insecure, //nolint:gosec
+		ServerName:         options.ServerName,
+	}
+	if options.MinVersion != "" {
+		version, ok := tlsVersions[options.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS version %q: expected 1.0, 1.1, 1.2 or 1.3", options.MinVersion)
+		}
+		config.MinVersion = version
+	}
+	if options.CACert != "" {
+		pem, err := ioutil.ReadFile(options.CACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %v", options.CACert)
+		}
+		config.RootCAs = pool
+	}
+	if options.Cert != "" {
+		key := options.Key
+		if key == "" {
+			key = options.Cert
+		}
+		certificate, err := tls.LoadX509KeyPair(options.Cert, key)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+// transportPool shares one transport, and so one pool of connections,
+// between every request using the same TLS options.
+type transportPool struct {
+	lock       sync.Mutex
+	transports map[TLSOptions]*http.Transport
+	create     func(config *tls.Config) *http.Transport
+}
+
+func (pool *transportPool) get(options TLSOptions) (*http.Transport, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	if transport, ok := pool.transports[options]; ok {
+		return transport, nil
+	}
+	var config *tls.Config
+	if options != (TLSOptions{}) {
+		var err error
+		if config, err = options.Config(); err != nil {
+			return nil, err
+		}
+	}
+	transport := pool.create(config)
+	pool.transports[options] = transport
+	return transport, nil
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// phaseTimer records how long each phase of a transaction takes. The
+// hooks may be called from the goroutines dialing the connection so the
+// times are guarded by a lock.
+type phaseTimer struct {
+	lock         sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	dns          time.Duration
+	connect      time.Duration
+	tls          time.Duration
+}
+
+func (timer *phaseTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			timer.mark(&timer.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			timer.measure(&timer.dns, timer.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			timer.mark(&timer.connectStart)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			timer.measure(&timer.connect, timer.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			timer.mark(&timer.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			timer.measure(&timer.tls, timer.tlsStart)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			timer.mark(&timer.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			timer.mark(&timer.firstByte)
+		},
+	}
+}
+
+func (timer *phaseTimer) mark(at *time.Time) {
+	timer.lock.Lock()
+	*at = time.Now()
+	timer.lock.Unlock()
+}
+
+func (timer *phaseTimer) measure(duration *time.Duration, start time.Time) {
+	timer.lock.Lock()
+	*duration = time.Since(start)
+	timer.lock.Unlock()
+}
+
+// record sets the phase timings on the result once the body has been read.
+// Phases which did not happen, such as connecting on a reused connection,
+// are left at zero.
+func (timer *phaseTimer) record(result *Result) {
+	timer.lock.Lock()
+	defer timer.lock.Unlock()
+	result.DNSTime = timer.dns
+	result.ConnectTime = timer.connect
+	result.TLSTime = timer.tls
+	if !timer.firstByte.IsZero() {
+		if !timer.wroteRequest.IsZero() {
+			result.FirstByteTime = timer.firstByte.Sub(timer.wroteRequest)
+		}
+		result.ContentTransferTime = time.Since(timer.firstByte)
+	}
+}
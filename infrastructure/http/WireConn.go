@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net"
+	"sync"
+)
+
+// countedAddr is the local address of a countingConn, which refers back to
+// the connection. A tls.Conn returns the local address of the connection
+// it wraps so the countingConn is found for HTTPS without unwrapping it.
+type countedAddr struct {
+	net.Addr
+	conn *countingConn
+}
+
+// countedConn returns the countingConn a connection given to a request was
+// dialled as.
+func countedConn(conn net.Conn) (*countingConn, bool) {
+	addr, ok := conn.LocalAddr().(countedAddr)
+	return addr.conn, ok
+}
+
+// WireMeter counts the bytes one transaction sent and received
+// on the wire, including TLS and chunked encoding overhead.
+type WireMeter struct {
+	lock     sync.Mutex
+	sent     int
+	received int
+}
+
+func (meter *WireMeter) add(sent, received int) {
+	meter.lock.Lock()
+	meter.sent += sent
+	meter.received += received
+	meter.lock.Unlock()
+}
+
+func (meter *WireMeter) counts() (sent, received int) {
+	meter.lock.Lock()
+	defer meter.lock.Unlock()
+	return meter.sent, meter.received
+}
+
+// attach makes the meter count the traffic of the connection from now on.
+func (meter *WireMeter) attach(conn net.Conn) {
+	if counted, ok := countedConn(conn); ok {
+		counted.attach(meter)
+	}
+}
+
+func (meter *WireMeter) record(result *Result) {
+	result.TotalBytesSent, result.TotalBytesReceived = meter.counts()
+}
+
+// countingConn counts the bytes read and written on a connection against
+// the meter of the transaction currently using it. Bytes such as the TLS
+// handshake, which happen before a transaction is given the connection,
+// are counted against the first transaction to use it. An HTTP/2
+// connection carries several transactions at once, its bytes are counted
+// against the transaction most recently given it so the totals of a run
+// stay exact while the split between those transactions is approximate.
+type countingConn struct {
+	net.Conn
+	lock     sync.Mutex
+	meter    *WireMeter
+	attached bool
+}
+
+func newCountingConn(conn net.Conn) *countingConn {
+	return &countingConn{
+		Conn:  conn,
+		meter: &WireMeter{},
+	}
+}
+
+func (conn *countingConn) attach(meter *WireMeter) {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+	if !conn.attached {
+		meter.add(conn.meter.counts())
+		conn.attached = true
+	}
+	conn.meter = meter
+}
+
+func (conn *countingConn) current() *WireMeter {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+	return conn.meter
+}
+
+func (conn *countingConn) LocalAddr() net.Addr {
+	return countedAddr{Addr: conn.Conn.LocalAddr(), conn: conn}
+}
+
+func (conn *countingConn) Read(b []byte) (int, error) {
+	n, err := conn.Conn.Read(b)
+	conn.current().add(0, n)
+	return n, err
+}
+
+func (conn *countingConn) Write(b []byte) (int, error) {
+	n, err := conn.Conn.Write(b)
+	conn.current().add(n, 0)
+	return n, err
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// RunCollector collects what a worker process streams of its run. Should
+// the stream break before the final result the last snapshot is kept,
+// marked as interrupted as it only covers part of the run.
+type RunCollector struct {
+	lock      sync.Mutex
+	progress  *ProgressResponse
+	response  *SchmokinResponse
+	intervals []*Interval
+	final     bool
+}
+
+// Collect receives the updates from the stream until it ends, returning
+// an error when it ended before the final result.
+func (collector *RunCollector) Collect(stream SchmokinService_RunStreamClient) error {
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			if !collector.finished() {
+				return errors.New("the run ended without a result")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		collector.update(update)
+	}
+}
+
+func (collector *RunCollector) update(update *RunUpdate) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	if update.Progress != nil {
+		collector.progress = update.Progress
+	}
+	if update.Result != nil {
+		collector.intervals = append(collector.intervals, update.Result.Intervals...)
+		collector.response = update.Result
+		collector.final = update.Final
+	}
+}
+
+func (collector *RunCollector) finished() bool {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	return collector.final
+}
+
+// Progress returns the last progress received, nil before any.
+func (collector *RunCollector) Progress() *ProgressResponse {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	return collector.progress
+}
+
+// Response returns the final result of the run, or the last snapshot of it
+// when there was none, with every interval received. It returns nil when
+// no result was received.
+func (collector *RunCollector) Response() *SchmokinResponse {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	if collector.response == nil {
+		return nil
+	}
+	response := *collector.response
+	response.Intervals = collector.intervals
+	response.Interrupted = response.Interrupted || !collector.final
+	return &response
+}
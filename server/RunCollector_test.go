@@ -0,0 +1,83 @@
+package server_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/server"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// fakeRunStream replays the updates and then fails with the error.
+type fakeRunStream struct {
+	grpc.ClientStream
+	updates []*server.RunUpdate
+	err     error
+}
+
+func (stream *fakeRunStream) Recv() (*server.RunUpdate, error) {
+	if len(stream.updates) == 0 {
+		return nil, stream.err
+	}
+	update := stream.updates[0]
+	stream.updates = stream.updates[1:]
+	return update, nil
+}
+
+func createSnapshot(transactions int, intervals ...int64) *server.SchmokinResponse {
+	response := createResponse(transactions, 0, time.Millisecond, time.Now(), time.Second)
+	for _, startTime := range intervals {
+		response.Intervals = append(response.Intervals, &server.Interval{StartTime: startTime, Transactions: 1})
+	}
+	return response
+}
+
+func Test_RunCollectorKeepsTheFinalResultWithEveryInterval(t *testing.T) {
+	collector := &server.RunCollector{}
+	err := collector.Collect(&fakeRunStream{
+		updates: []*server.RunUpdate{
+			{Progress: &server.ProgressResponse{WarmingUp: true}},
+			{Progress: &server.ProgressResponse{Transactions: 10}, Result: createSnapshot(10, 1)},
+			{Progress: &server.ProgressResponse{Transactions: 20}, Result: createSnapshot(20, 2, 3), Final: true},
+		},
+		err: io.EOF,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), collector.Progress().Transactions)
+	response := collector.Response()
+	assert.Equal(t, int32(20), response.Transactions)
+	assert.False(t, response.Interrupted)
+	assert.Len(t, response.Intervals, 3)
+}
+
+func Test_RunCollectorKeepsTheLastSnapshotWhenTheStreamBreaks(t *testing.T) {
+	collector := &server.RunCollector{}
+	err := collector.Collect(&fakeRunStream{
+		updates: []*server.RunUpdate{
+			{Progress: &server.ProgressResponse{Transactions: 10}, Result: createSnapshot(10, 1)},
+			{Progress: &server.ProgressResponse{Transactions: 20}, Result: createSnapshot(20, 2)},
+		},
+		err: errors.New("transport is closing"),
+	})
+
+	assert.NotNil(t, err)
+	response := collector.Response()
+	assert.Equal(t, int32(20), response.Transactions)
+	assert.True(t, response.Interrupted)
+	assert.Len(t, response.Intervals, 2)
+}
+
+func Test_RunCollectorReturnsAnErrorWhenTheStreamEndsWithoutAResult(t *testing.T) {
+	collector := &server.RunCollector{}
+	err := collector.Collect(&fakeRunStream{
+		updates: []*server.RunUpdate{{Progress: &server.ProgressResponse{WarmingUp: true}}},
+		err:     io.EOF,
+	})
+
+	assert.NotNil(t, err)
+	assert.Nil(t, collector.Response())
+}
@@ -4,6 +4,8 @@ import (
 	context "context"
 	"log"
 	"net"
+	"sync"
+	"time"
 
 	empty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/pkg/errors"
@@ -15,20 +17,72 @@ import (
 
 var server *grpc.Server
 
+// SnapshotInterval is how often RunStream sends an update.
+const SnapshotInterval = time.Second
+
 type schmokinRemoteService struct {
+	interrupted chan struct{}
+	interrupt   sync.Once
+}
+
+// interruptRuns interrupts every run in progress and any started later,
+// the runs return the results of the transactions made until then.
+func (s *schmokinRemoteService) interruptRuns() {
+	s.interrupt.Do(func() {
+		close(s.interrupted)
+	})
+}
+
+// interruptible returns a context which is cancelled along with the
+// context of the call or when the runs are interrupted.
+func (s *schmokinRemoteService) interruptible(ctx context.Context) (context.Context, context.CancelFunc) {
+	interrupt, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.interrupted:
+			cancel()
+		case <-interrupt.Done():
+		}
+	}()
+	return interrupt, cancel
 }
 
-func (s *schmokinRemoteService) Run(ctx context.Context, in *SchmokinRequest) (*SchmokinResponse, error) {
-	schmokinService := service.NewSchmokinServiceBuilder().
+func newSchmokinService(in *SchmokinRequest) *service.SchmokinService {
+	return service.NewSchmokinServiceBuilder().
 		SetClient(schmokinHTTP.NewDefaultClient()).
 		SetIterations(int(in.Iterations)).
+		SetDuration(time.Duration(in.Duration)).
+		SetStages(serviceStages(in.Stages)).
+		SetRate(in.Rate).
+		SetMaxWorkers(int(in.MaxWorkers)).
+		SetPoisson(in.Poisson).
+		SetWarmup(service.Warmup{
+			Duration:   time.Duration(in.WarmupDuration),
+			Iterations: int(in.WarmupIterations),
+		}).
+		SetInterval(time.Duration(in.Interval)).
+		SetHeaders(in.Headers).
+		SetTimeout(time.Duration(in.Timeout)).
+		SetTLSOptions(schmokinHTTP.TLSOptions{
+			Insecure:   in.Insecure,
+			CACert:     in.Cacert,
+			Cert:       in.Cert,
+			Key:        in.Key,
+			MinVersion: in.TlsMinVersion,
+			ServerName: in.ServerName,
+		}).
+		SetCookieJar(in.CookieJar).
+		SetSaveCookies(in.SaveCookies).
+		SetProcess(int(in.Process)).
+		SetCaptureSize(in.CaptureSize).
 		SetRandom(in.Random).
 		SetTimer(utils.NewDefaultTimer()).
 		SetWorkers(int(in.WorkerCount)).
 		Build()
+}
 
-	result := schmokinService.Execute(in.Lines)
-
+// newSchmokinResponse returns the response with the result of the run.
+func newSchmokinResponse(in *SchmokinRequest, result service.SchmokinResult) *SchmokinResponse {
 	response := &SchmokinResponse{
 		Transactions:           int32(result.Transactions),
 		Availability:           result.Availability,
@@ -38,14 +92,146 @@ func (s *schmokinRemoteService) Run(ctx context.Context, in *SchmokinRequest) (*
 		DataReceiveRate:        result.DataReceiveRate,
 		DataSendRate:           result.DataSendRate,
 		FailedTransactions:     result.FailedTransactions,
+		ErroredTransactions:    result.ErroredTransactions,
+		TimedOutTransactions:   result.TimedOutTransactions,
+		DroppedIterations:      result.DroppedIterations,
+		LateIterations:         result.LateIterations,
+		DNSLookupTime:          NewDistribution(result.DNSLookupTime),
+		ConnectTime:            NewDistribution(result.ConnectTime),
+		TLSHandshakeTime:       NewDistribution(result.TLSHandshakeTime),
+		FirstByteTime:          NewDistribution(result.FirstByteTime),
+		ContentTransferTime:    NewDistribution(result.ContentTransferTime),
 		LongestTransaction:     result.LongestTransaction,
 		ShortestTransaction:    result.ShortestTransaction,
+		ResponseTime:           NewDistribution(result.ResponseTime),
+		CorrectedResponseTime:  NewDistribution(result.CorrectedResponseTime),
 		SuccessfulTransactions: result.SuccessfulTransactions,
-		TotalBytesReceived:     int32(result.TotalBytesReceived),
-		TotalBytesSent:         int32(result.TotalBytesSent),
+		TotalBytesReceived:     int64(result.TotalBytesReceived),
+		TotalBytesSent:         int64(result.TotalBytesSent),
 		TransactionRate:        result.TransactionRate,
+		StartTime:              result.StartTime.UnixNano(),
+		EndTime:                result.EndTime.UnixNano(),
+		Stages:                 NewStageResults(result.Stages),
+		Endpoints:              NewEndpointResults(result.Endpoints),
+		Intervals:              NewIntervals(result.Intervals),
+		StatusCodes:            NewStatusCodes(result.StatusCodes),
+		ErrorCategories:        result.ErrorCategories,
+		Interrupted:            result.Interrupted,
+	}
+	if in.WarmupDuration > 0 || in.WarmupIterations > 0 {
+		response.Warmup = NewStageResult(result.Warmup)
+	}
+	return response
+}
+
+func newProgressResponse(progress service.Progress) *ProgressResponse {
+	return &ProgressResponse{
+		WarmingUp:           progress.WarmingUp,
+		Transactions:        int64(progress.Transactions),
+		FailedTransactions:  progress.FailedTransactions,
+		ErroredTransactions: progress.ErroredTransactions,
+		ActiveUsers:         int32(progress.ActiveUsers),
+		ResponseTime:        NewDistribution(progress.ResponseTime),
+	}
+}
+
+// progressWindow works out the response times recorded between one
+// progress and the next from the response times of the run so far, so the
+// progress shows how the run is going now rather than overall.
+type progressWindow struct {
+	previous *utils.Histogram
+}
+
+func (window *progressWindow) recent(progress service.Progress) service.Progress {
+	responseTime := progress.ResponseTime.Histogram
+	if window.previous != nil {
+		progress.ResponseTime = service.NewDistribution(responseTime.Since(window.previous))
 	}
-	return response, nil
+	window.previous = responseTime
+	return progress
+}
+
+// RunStream runs the lines while sending an update every snapshot interval
+// with the progress and the result so far, then one with the final result.
+// Each update only carries the intervals which ended since the last so
+// every interval is built and sent once.
+func (s *schmokinRemoteService) RunStream(in *SchmokinRequest, stream SchmokinService_RunStreamServer) error {
+	interrupt, cancel := s.interruptible(stream.Context())
+	defer cancel()
+
+	schmokinService := newSchmokinService(in)
+	finished := make(chan service.SchmokinResult, 1)
+	go func() {
+		finished <- schmokinService.Execute(interrupt, in.Lines)
+	}()
+
+	// next is when the intervals not sent yet start from, kept apart for
+	// the warm-up as its last interval can start when the run's first does
+	next := map[bool]time.Time{}
+	// unsent is when the earliest intervals not sent yet start from
+	unsent := func() time.Time {
+		if next[true].Before(next[false]) {
+			return next[true]
+		}
+		return next[false]
+	}
+	// unsentIntervals leaves out of the response the intervals already sent
+	// and, unless the run has finished, those which have not ended yet.
+	unsentIntervals := func(response *SchmokinResponse, now time.Time, final bool) {
+		intervals := []*Interval{}
+		for _, interval := range response.Intervals {
+			end := time.Unix(0, interval.StartTime+interval.Duration)
+			if time.Unix(0, interval.StartTime).Before(next[interval.Warmup]) || (!final && end.After(now)) {
+				continue
+			}
+			next[interval.Warmup] = end
+			intervals = append(intervals, interval)
+		}
+		response.Intervals = intervals
+	}
+	window := progressWindow{}
+
+	ticker := time.NewTicker(SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case result := <-finished:
+			response := newSchmokinResponse(in, result)
+			unsentIntervals(response, time.Now(), true)
+			return stream.Send(&RunUpdate{
+				Progress: newProgressResponse(window.recent(schmokinService.Progress())),
+				Result:   response,
+				Final:    true,
+			})
+		case now := <-ticker.C:
+			update := &RunUpdate{
+				Progress: newProgressResponse(window.recent(schmokinService.Progress())),
+			}
+			// There is no result until the warm-up has finished
+			if snapshot := schmokinService.Snapshot(unsent()); !snapshot.StartTime.IsZero() {
+				update.Result = newSchmokinResponse(in, snapshot)
+				unsentIntervals(update.Result, now, false)
+			}
+			if err := stream.Send(update); err != nil {
+				// Nothing is left to send the result to
+				cancel()
+				result := <-finished
+				log.Printf("Run finished with %v transactions after the stream broke: %v", result.Transactions, err)
+				return err
+			}
+		}
+	}
+}
+
+func serviceStages(stages []*Stage) []service.Stage {
+	result := []service.Stage{}
+	for _, stage := range stages {
+		result = append(result, service.Stage{
+			Duration: time.Duration(stage.Duration),
+			Target:   int(stage.Target),
+		})
+	}
+	return result
 }
 
 func (s *schmokinRemoteService) Ping(ctx context.Context, in *empty.Empty) (*PingResponse, error) {
@@ -61,7 +247,16 @@ func (s *schmokinRemoteService) Kill(ctx context.Context, in *empty.Empty) (*Kil
 	}, nil
 }
 
-func StartServer(address string) {
+func (s *schmokinRemoteService) Interrupt(ctx context.Context, in *empty.Empty) (*InterruptResponse, error) {
+	s.interruptRuns()
+	return &InterruptResponse{
+		Interrupted: true,
+	}, nil
+}
+
+// StartServer serves runs on the address until the server is killed,
+// cancelling the context interrupts the runs.
+func StartServer(ctx context.Context, address string) {
 	lis, err := net.Listen("tcp", address)
 	log.Println("Server starting on " + address)
 	if err != nil {
@@ -69,7 +264,14 @@ func StartServer(address string) {
 	}
 
 	server = grpc.NewServer()
-	RegisterSchmokinServiceServer(server, &schmokinRemoteService{})
+	remoteService := &schmokinRemoteService{
+		interrupted: make(chan struct{}),
+	}
+	go func() {
+		<-ctx.Done()
+		remoteService.interruptRuns()
+	}()
+	RegisterSchmokinServiceServer(server, remoteService)
 
 	if err := server.Serve(lis); err != nil {
 		log.Fatal(errors.Wrap(err, "Failed to start server!"))
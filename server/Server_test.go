@@ -0,0 +1,132 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/reaandrew/schmokin/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T) server.SchmokinServiceClient {
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	go server.StartServer(context.Background(), address)
+	client := server.CreateClient(address)
+	for i := 0; i < 100; i++ {
+		if _, err := client.Ping(context.Background(), &empty.Empty{}); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return client
+}
+
+func Test_RunStreamSendsSnapshotsAndThenTheFinalResult(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer target.Close()
+	client := startServer(t)
+	defer client.Kill(context.Background(), &empty.Empty{})
+
+	stream, err := client.RunStream(context.Background(), &server.SchmokinRequest{
+		Lines:       []string{fmt.Sprintf("%v/1 -X GET", target.URL)},
+		WorkerCount: 2,
+		Duration:    int64(2500 * time.Millisecond),
+		Interval:    int64(500 * time.Millisecond),
+	})
+	assert.Nil(t, err)
+
+	updates := []*server.RunUpdate{}
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		updates = append(updates, update)
+	}
+
+	assert.True(t, len(updates) >= 3, "updates %v", len(updates))
+	final := updates[len(updates)-1]
+	assert.True(t, final.Final)
+	assert.False(t, final.Result.Interrupted)
+	intervals := map[int64]bool{}
+	transactions := 0
+	responseTimes := 0
+	for index, update := range updates {
+		assert.NotNil(t, update.Progress)
+		// Each progress only has the response times since the last
+		responseTimes += int(update.Progress.ResponseTime.Count)
+		assert.Equal(t, index == len(updates)-1, update.Final)
+		if index > 0 {
+			assert.True(t, update.Result.Transactions >= updates[index-1].Result.Transactions)
+		}
+		for _, interval := range update.Result.Intervals {
+			assert.False(t, intervals[interval.StartTime], "interval sent twice")
+			intervals[interval.StartTime] = true
+			transactions += int(interval.Transactions)
+		}
+	}
+	assert.Equal(t, int(final.Result.Transactions), transactions)
+	assert.Equal(t, int(final.Result.Transactions), responseTimes)
+}
+
+func Test_RunStreamSendsTheIntervalsOfTheWarmupAndTheRun(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer target.Close()
+	client := startServer(t)
+	defer client.Kill(context.Background(), &empty.Empty{})
+
+	// The warm-up ends part way through an interval, the run's first
+	// interval starts at the same time as the warm-up's last
+	stream, err := client.RunStream(context.Background(), &server.SchmokinRequest{
+		Lines:          []string{fmt.Sprintf("%v/1 -X GET", target.URL)},
+		WorkerCount:    2,
+		Duration:       int64(1500 * time.Millisecond),
+		Interval:       int64(400 * time.Millisecond),
+		WarmupDuration: int64(1300 * time.Millisecond),
+	})
+	assert.Nil(t, err)
+
+	var final *server.RunUpdate
+	type intervalKey struct {
+		startTime int64
+		warmup    bool
+	}
+	intervals := map[intervalKey]bool{}
+	transactions := map[bool]int{}
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		if update.Result == nil {
+			continue
+		}
+		for _, interval := range update.Result.Intervals {
+			key := intervalKey{interval.StartTime, interval.Warmup}
+			assert.False(t, intervals[key], "interval sent twice")
+			intervals[key] = true
+			transactions[interval.Warmup] += int(interval.Transactions)
+		}
+		final = update
+	}
+
+	assert.True(t, final.Final)
+	assert.Equal(t, int(final.Result.Warmup.Transactions), transactions[true])
+	assert.Equal(t, int(final.Result.Transactions), transactions[false])
+}
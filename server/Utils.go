@@ -1,54 +1,404 @@
 package server
 
 import (
+	"sort"
+	"time"
+
 	"github.com/reaandrew/schmokin/service"
 	"github.com/reaandrew/schmokin/utils"
 )
 
-func MergeResponses(responses []*SchmokinResponse) (result *service.SchmokinResult) {
-	result = &service.SchmokinResult{}
-	availabilities := []float64{}
-	responseTimes := []float64{}
-	concurrencyRate := []float64{}
-	dateReceiveRates := []float64{}
-	dataSendRates := []float64{}
-	failedTransactions := []int64{}
-	longestTransactions := []int64{}
-	shortestTransactions := []int64{}
-	successfulTransactions := []int64{}
-	totalBytesReceived := []int64{}
-	totalBytesSent := []int64{}
-	transactions := []int64{}
-	transactionRates := []float64{}
+// MergeResponses combines the results of each worker process from their
+// raw counts and histograms rather than averaging their averages. Rates
+// are the totals over the wall clock window from the first worker
+// starting to the last worker finishing.
+func MergeResponses(responses []*SchmokinResponse) (result *service.SchmokinResult, err error) {
+	result = &service.SchmokinResult{
+		StatusCodes:     map[int]int64{},
+		ErrorCategories: map[string]int64{},
+	}
+	responseTimes := []*Distribution{}
+	correctedResponseTimes := []*Distribution{}
+	dnsLookupTimes := []*Distribution{}
+	connectTimes := []*Distribution{}
+	tlsHandshakeTimes := []*Distribution{}
+	firstByteTimes := []*Distribution{}
+	contentTransferTimes := []*Distribution{}
+	var startTime, endTime int64
+
+	for _, response := range responses {
+		result.Transactions += int(response.Transactions)
+		result.SuccessfulTransactions += response.SuccessfulTransactions
+		result.FailedTransactions += response.FailedTransactions
+		result.ErroredTransactions += response.ErroredTransactions
+		result.TimedOutTransactions += response.TimedOutTransactions
+		result.DroppedIterations += response.DroppedIterations
+		result.LateIterations += response.LateIterations
+		result.TotalBytesSent += int(response.TotalBytesSent)
+		result.TotalBytesReceived += int(response.TotalBytesReceived)
+		// Each process runs its own virtual users alongside the others
+		// so their concurrency adds up.
+		result.ConcurrencyRate += response.ConcurrencyRate
+		// Any process being interrupted makes the whole result partial
+		result.Interrupted = result.Interrupted || response.Interrupted
+		for code, count := range response.StatusCodes {
+			result.StatusCodes[int(code)] += count
+		}
+		for category, count := range response.ErrorCategories {
+			result.ErrorCategories[category] += count
+		}
+		if startTime == 0 || response.StartTime < startTime {
+			startTime = response.StartTime
+		}
+		if response.EndTime > endTime {
+			endTime = response.EndTime
+		}
+		responseTimes = append(responseTimes, response.ResponseTime)
+		correctedResponseTimes = append(correctedResponseTimes, response.CorrectedResponseTime)
+		dnsLookupTimes = append(dnsLookupTimes, response.DNSLookupTime)
+		connectTimes = append(connectTimes, response.ConnectTime)
+		tlsHandshakeTimes = append(tlsHandshakeTimes, response.TLSHandshakeTime)
+		firstByteTimes = append(firstByteTimes, response.FirstByteTime)
+		contentTransferTimes = append(contentTransferTimes, response.ContentTransferTime)
+	}
+
+	if result.ResponseTime, err = MergeDistributions(responseTimes); err != nil {
+		return
+	}
+	if result.CorrectedResponseTime, err = MergeDistributions(correctedResponseTimes); err != nil {
+		return
+	}
+	if result.DNSLookupTime, err = MergeDistributions(dnsLookupTimes); err != nil {
+		return
+	}
+	if result.ConnectTime, err = MergeDistributions(connectTimes); err != nil {
+		return
+	}
+	if result.TLSHandshakeTime, err = MergeDistributions(tlsHandshakeTimes); err != nil {
+		return
+	}
+	if result.FirstByteTime, err = MergeDistributions(firstByteTimes); err != nil {
+		return
+	}
+	if result.ContentTransferTime, err = MergeDistributions(contentTransferTimes); err != nil {
+		return
+	}
+	result.AverageResponseTime = result.ResponseTime.Mean
+	result.LongestTransaction = result.ResponseTime.Max
+	result.ShortestTransaction = result.ResponseTime.Min
+
+	if len(responses) > 0 {
+		result.StartTime = time.Unix(0, startTime)
+		result.EndTime = time.Unix(0, endTime)
+		result.ElapsedTime = result.EndTime.Sub(result.StartTime)
+	}
+	if seconds := result.ElapsedTime.Seconds(); seconds > 0 {
+		result.TransactionRate = float64(result.Transactions) / seconds
+		result.DataSendRate = float64(result.TotalBytesSent) / seconds
+		result.DataReceiveRate = float64(result.TotalBytesReceived) / seconds
+	}
+
+	result.SetOutcomeRates()
+	if result.Stages, err = MergeStages(responses); err != nil {
+		return
+	}
+	warmups := []*StageResult{}
+	for _, response := range responses {
+		warmups = append(warmups, response.Warmup)
+	}
+	if result.Warmup, err = MergeStage(warmups); err != nil {
+		return
+	}
+	if result.Endpoints, err = MergeEndpoints(responses, result.ElapsedTime); err != nil {
+		return
+	}
+	result.Intervals, err = MergeIntervals(responses)
+	return
+}
+
+// intervalKey identifies the intervals of each worker process to merge,
+// those of the warm-up are kept apart from those of the run.
+type intervalKey struct {
+	startTime int64
+	warmup    bool
+}
 
+// MergeIntervals combines the intervals each worker process recorded
+// starting at the same time, ordered by time. The processes run their
+// own virtual users alongside the others so their active users add up.
+func MergeIntervals(responses []*SchmokinResponse) (result []service.Interval, err error) {
+	intervals := map[intervalKey]*service.Interval{}
+	responseTimes := map[intervalKey][]*Distribution{}
 	for _, response := range responses {
-		availabilities = append(availabilities, response.Availability)
-		responseTimes = append(responseTimes, response.AverageResponseTime)
-		concurrencyRate = append(concurrencyRate, response.ConcurrencyRate)
-		dateReceiveRates = append(dateReceiveRates, response.DataReceiveRate)
-		dataSendRates = append(dataSendRates, response.DataSendRate)
-		failedTransactions = append(failedTransactions, response.FailedTransactions)
-		longestTransactions = append(longestTransactions, response.LongestTransaction)
-		shortestTransactions = append(shortestTransactions, response.ShortestTransaction)
-		successfulTransactions = append(successfulTransactions, response.SuccessfulTransactions)
-		totalBytesReceived = append(totalBytesReceived, int64(response.TotalBytesReceived))
-		totalBytesSent = append(totalBytesSent, int64(response.TotalBytesSent))
-		transactions = append(transactions, int64(response.Transactions))
-		transactionRates = append(transactionRates, response.TransactionRate)
-	}
-
-	result.Availability = utils.AverageFloat64(availabilities)
-	result.AverageResponseTime = utils.AverageFloat64(responseTimes)
-	result.ConcurrencyRate = utils.AverageFloat64(concurrencyRate)
-	result.DataReceiveRate = utils.AverageFloat64(dateReceiveRates)
-	result.DataSendRate = utils.AverageFloat64(dataSendRates)
-	result.FailedTransactions = utils.Sum(failedTransactions)
-	result.LongestTransaction = utils.Max(longestTransactions)
-	result.ShortestTransaction = utils.Min(shortestTransactions)
-	result.SuccessfulTransactions = utils.Sum(successfulTransactions)
-	result.TotalBytesReceived = int(utils.Sum(totalBytesReceived))
-	result.TotalBytesSent = int(utils.Sum(totalBytesSent))
-	result.Transactions = int(utils.Sum(transactions))
-	result.TransactionRate = utils.AverageFloat64(transactionRates)
+		for _, processInterval := range response.Intervals {
+			key := intervalKey{processInterval.StartTime, processInterval.Warmup}
+			interval, ok := intervals[key]
+			if !ok {
+				interval = &service.Interval{
+					StartTime: time.Unix(0, processInterval.StartTime),
+					Duration:  time.Duration(processInterval.Duration),
+					Warmup:    processInterval.Warmup,
+				}
+				intervals[key] = interval
+			}
+			interval.Transactions += int(processInterval.Transactions)
+			interval.SuccessfulTransactions += processInterval.SuccessfulTransactions
+			interval.FailedTransactions += processInterval.FailedTransactions
+			interval.ErroredTransactions += processInterval.ErroredTransactions
+			interval.TimedOutTransactions += processInterval.TimedOutTransactions
+			interval.TotalBytesSent += int(processInterval.TotalBytesSent)
+			interval.TotalBytesReceived += int(processInterval.TotalBytesReceived)
+			interval.ActiveUsers += int(processInterval.ActiveUsers)
+			responseTimes[key] = append(responseTimes[key], processInterval.ResponseTime)
+		}
+	}
+	keys := []intervalKey{}
+	for key := range intervals {
+		keys = append(keys, key)
+	}
+	// The warm-up's interval comes first of those starting at once
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].startTime == keys[j].startTime {
+			return keys[i].warmup && !keys[j].warmup
+		}
+		return keys[i].startTime < keys[j].startTime
+	})
+	for _, key := range keys {
+		interval := intervals[key]
+		if interval.ResponseTime, err = MergeDistributions(responseTimes[key]); err != nil {
+			return
+		}
+		result = append(result, *interval)
+	}
+	return
+}
+
+// MergeProgress combines the snapshots of the run on each worker process.
+func MergeProgress(responses []*ProgressResponse) (result service.Progress, err error) {
+	responseTimes := []*Distribution{}
+	for _, response := range responses {
+		result.WarmingUp = result.WarmingUp || response.WarmingUp
+		result.Transactions += int(response.Transactions)
+		result.FailedTransactions += response.FailedTransactions
+		result.ErroredTransactions += response.ErroredTransactions
+		result.ActiveUsers += int(response.ActiveUsers)
+		responseTimes = append(responseTimes, response.ResponseTime)
+	}
+	result.ResponseTime, err = MergeDistributions(responseTimes)
+	return
+}
+
+// MergeEndpoints combines the results each worker process recorded for
+// the same endpoint, ordered by name, with the transaction rate over the
+// wall clock window of the run.
+func MergeEndpoints(responses []*SchmokinResponse, elapsed time.Duration) (result []service.EndpointResult, err error) {
+	endpoints := map[string]*service.EndpointResult{}
+	responseTimes := map[string][]*Distribution{}
+	for _, response := range responses {
+		for _, processEndpoint := range response.Endpoints {
+			endpoint, ok := endpoints[processEndpoint.Name]
+			if !ok {
+				endpoint = &service.EndpointResult{Name: processEndpoint.Name}
+				endpoints[processEndpoint.Name] = endpoint
+			}
+			endpoint.Transactions += int(processEndpoint.Transactions)
+			endpoint.SuccessfulTransactions += processEndpoint.SuccessfulTransactions
+			endpoint.FailedTransactions += processEndpoint.FailedTransactions
+			endpoint.ErroredTransactions += processEndpoint.ErroredTransactions
+			endpoint.TimedOutTransactions += processEndpoint.TimedOutTransactions
+			endpoint.TotalBytesSent += int(processEndpoint.TotalBytesSent)
+			endpoint.TotalBytesReceived += int(processEndpoint.TotalBytesReceived)
+			responseTimes[processEndpoint.Name] = append(responseTimes[processEndpoint.Name], processEndpoint.ResponseTime)
+		}
+	}
+	names := []string{}
+	for name := range endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		endpoint := endpoints[name]
+		if endpoint.ResponseTime, err = MergeDistributions(responseTimes[name]); err != nil {
+			return
+		}
+		if seconds := elapsed.Seconds(); seconds > 0 {
+			endpoint.TransactionRate = float64(endpoint.Transactions) / seconds
+		}
+		result = append(result, *endpoint)
+	}
+	return
+}
+
+// MergeStages combines the results each worker process recorded for the
+// same stage, the window of a stage runs from the first process starting
+// it to the last process finishing it.
+func MergeStages(responses []*SchmokinResponse) (result []service.StageResult, err error) {
+	stages := 0
+	for _, response := range responses {
+		if len(response.Stages) > stages {
+			stages = len(response.Stages)
+		}
+	}
+	for index := 0; index < stages; index++ {
+		processStages := []*StageResult{}
+		for _, response := range responses {
+			if index < len(response.Stages) {
+				processStages = append(processStages, response.Stages[index])
+			}
+		}
+		stage, err := MergeStage(processStages)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, stage)
+	}
+	return
+}
+
+// MergeStage combines the results each worker process recorded for one
+// stage, or for the warm-up.
+func MergeStage(processStages []*StageResult) (stage service.StageResult, err error) {
+	responseTimes := []*Distribution{}
+	for _, processStage := range processStages {
+		if processStage == nil {
+			continue
+		}
+		startTime := time.Unix(0, processStage.StartTime)
+		endTime := time.Unix(0, processStage.EndTime)
+		if stage.StartTime.IsZero() || startTime.Before(stage.StartTime) {
+			stage.StartTime = startTime
+		}
+		if endTime.After(stage.EndTime) {
+			stage.EndTime = endTime
+		}
+		stage.Duration = time.Duration(processStage.Duration)
+		stage.Target += int(processStage.Target)
+		stage.Transactions += int(processStage.Transactions)
+		stage.SuccessfulTransactions += processStage.SuccessfulTransactions
+		stage.FailedTransactions += processStage.FailedTransactions
+		stage.ErroredTransactions += processStage.ErroredTransactions
+		stage.TimedOutTransactions += processStage.TimedOutTransactions
+		stage.TotalBytesSent += int(processStage.TotalBytesSent)
+		stage.TotalBytesReceived += int(processStage.TotalBytesReceived)
+		responseTimes = append(responseTimes, processStage.ResponseTime)
+	}
+	stage.ResponseTime, err = MergeDistributions(responseTimes)
+	return
+}
+
+func NewDistribution(distribution service.Distribution) *Distribution {
+	response := &Distribution{
+		Count:  distribution.Count,
+		Mean:   distribution.Mean,
+		Min:    distribution.Min,
+		Max:    distribution.Max,
+		StdDev: distribution.StdDev,
+		P50:    distribution.P50,
+		P75:    distribution.P75,
+		P90:    distribution.P90,
+		P95:    distribution.P95,
+		P99:    distribution.P99,
+		P999:   distribution.P999,
+	}
+	if distribution.Histogram != nil {
+		response.Histogram = distribution.Histogram.Encode()
+	}
+	return response
+}
+
+func NewStages(stages []service.Stage) []*Stage {
+	result := []*Stage{}
+	for _, stage := range stages {
+		result = append(result, &Stage{
+			Duration: int64(stage.Duration),
+			Target:   int32(stage.Target),
+		})
+	}
+	return result
+}
+
+func NewStageResults(stages []service.StageResult) []*StageResult {
+	result := []*StageResult{}
+	for _, stage := range stages {
+		result = append(result, NewStageResult(stage))
+	}
 	return result
 }
+
+func NewStageResult(stage service.StageResult) *StageResult {
+	return &StageResult{
+		Duration:               int64(stage.Duration),
+		Target:                 int32(stage.Target),
+		StartTime:              stage.StartTime.UnixNano(),
+		EndTime:                stage.EndTime.UnixNano(),
+		Transactions:           int64(stage.Transactions),
+		SuccessfulTransactions: stage.SuccessfulTransactions,
+		FailedTransactions:     stage.FailedTransactions,
+		ErroredTransactions:    stage.ErroredTransactions,
+		TimedOutTransactions:   stage.TimedOutTransactions,
+		TotalBytesSent:         int64(stage.TotalBytesSent),
+		TotalBytesReceived:     int64(stage.TotalBytesReceived),
+		ResponseTime:           NewDistribution(stage.ResponseTime),
+	}
+}
+
+func NewStatusCodes(statusCodes map[int]int64) map[int32]int64 {
+	result := map[int32]int64{}
+	for code, count := range statusCodes {
+		result[int32(code)] = count
+	}
+	return result
+}
+
+func NewEndpointResults(endpoints []service.EndpointResult) []*EndpointResult {
+	result := []*EndpointResult{}
+	for _, endpoint := range endpoints {
+		result = append(result, &EndpointResult{
+			Name:                   endpoint.Name,
+			Transactions:           int64(endpoint.Transactions),
+			SuccessfulTransactions: endpoint.SuccessfulTransactions,
+			FailedTransactions:     endpoint.FailedTransactions,
+			ErroredTransactions:    endpoint.ErroredTransactions,
+			TimedOutTransactions:   endpoint.TimedOutTransactions,
+			TotalBytesSent:         int64(endpoint.TotalBytesSent),
+			TotalBytesReceived:     int64(endpoint.TotalBytesReceived),
+			ResponseTime:           NewDistribution(endpoint.ResponseTime),
+		})
+	}
+	return result
+}
+
+func NewIntervals(intervals []service.Interval) []*Interval {
+	result := []*Interval{}
+	for _, interval := range intervals {
+		result = append(result, &Interval{
+			StartTime:              interval.StartTime.UnixNano(),
+			Duration:               int64(interval.Duration),
+			Transactions:           int64(interval.Transactions),
+			SuccessfulTransactions: interval.SuccessfulTransactions,
+			FailedTransactions:     interval.FailedTransactions,
+			ErroredTransactions:    interval.ErroredTransactions,
+			TimedOutTransactions:   interval.TimedOutTransactions,
+			TotalBytesSent:         int64(interval.TotalBytesSent),
+			TotalBytesReceived:     int64(interval.TotalBytesReceived),
+			ActiveUsers:            int32(interval.ActiveUsers),
+			ResponseTime:           NewDistribution(interval.ResponseTime),
+			Warmup:                 interval.Warmup,
+		})
+	}
+	return result
+}
+
+// MergeDistributions combines the histograms recorded by each worker so
+// the merged percentiles are those of every duration recorded.
+func MergeDistributions(distributions []*Distribution) (result service.Distribution, err error) {
+	merged := utils.NewHistogram()
+	for _, distribution := range distributions {
+		histogram, err := utils.DecodeHistogram(distribution.GetHistogram())
+		if err != nil {
+			return result, err
+		}
+		merged.Merge(histogram)
+	}
+	return service.NewDistribution(merged), nil
+}
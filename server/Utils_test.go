@@ -0,0 +1,275 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/server"
+	"github.com/reaandrew/schmokin/service"
+	"github.com/reaandrew/schmokin/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createResponse(transactions int, failed int64, responseTime time.Duration, start time.Time, elapsed time.Duration) *server.SchmokinResponse {
+	histogram := utils.NewHistogram()
+	for i := 0; i < transactions; i++ {
+		histogram.Record(int64(responseTime))
+	}
+	return &server.SchmokinResponse{
+		Transactions:           int32(transactions),
+		SuccessfulTransactions: int64(transactions) - failed,
+		FailedTransactions:     failed,
+		TotalBytesSent:         int64(transactions * 100),
+		TotalBytesReceived:     int64(transactions * 200),
+		ResponseTime:           server.NewDistribution(service.NewDistribution(histogram)),
+		StartTime:              start.UnixNano(),
+		EndTime:                start.Add(elapsed).UnixNano(),
+	}
+}
+
+func Test_MergeResponsesWeightsAvailabilityByTransactions(t *testing.T) {
+	start := time.Now()
+	errored := createResponse(10, 0, time.Millisecond, start, time.Second)
+	errored.SuccessfulTransactions = 0
+	errored.ErroredTransactions = 10
+	result, err := server.MergeResponses([]*server.SchmokinResponse{
+		errored,
+		createResponse(990, 0, time.Millisecond, start, time.Second),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, result.Transactions)
+	assert.Equal(t, int64(10), result.ErroredTransactions)
+	assert.Equal(t, 0.99, result.Availability)
+	assert.Equal(t, 0.99, result.SuccessRate)
+}
+
+func Test_MergeResponsesWeightsSuccessRateByTransactions(t *testing.T) {
+	start := time.Now()
+	result, err := server.MergeResponses([]*server.SchmokinResponse{
+		createResponse(10, 10, time.Millisecond, start, time.Second),
+		createResponse(990, 0, time.Millisecond, start, time.Second),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), result.Availability)
+	assert.Equal(t, 0.99, result.SuccessRate)
+	assert.Equal(t, 0.01, result.FailureRate)
+}
+
+func Test_MergeResponsesSumsRatesOverTheWallClockWindow(t *testing.T) {
+	start := time.Now()
+	result, err := server.MergeResponses([]*server.SchmokinResponse{
+		createResponse(100, 0, time.Millisecond, start, time.Second),
+		createResponse(300, 0, time.Millisecond, start.Add(time.Second), time.Second),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Second, result.ElapsedTime)
+	assert.Equal(t, float64(200), result.TransactionRate)
+	assert.Equal(t, float64(20000), result.DataSendRate)
+	assert.Equal(t, float64(40000), result.DataReceiveRate)
+}
+
+func Test_MergeResponsesMergesPercentilesExactly(t *testing.T) {
+	start := time.Now()
+	result, err := server.MergeResponses([]*server.SchmokinResponse{
+		createResponse(10, 0, time.Second, start, time.Second),
+		createResponse(990, 0, time.Millisecond, start, time.Second),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1000), result.ResponseTime.Count)
+	assert.InEpsilon(t, int64(time.Millisecond), result.ResponseTime.P50, 0.001)
+	assert.InEpsilon(t, int64(time.Millisecond), result.ResponseTime.P99, 0.001)
+	assert.InEpsilon(t, int64(time.Second), result.ResponseTime.P999, 0.001)
+	assert.Equal(t, int64(time.Second), result.LongestTransaction)
+	assert.InEpsilon(t, float64(10*time.Second+990*time.Millisecond)/1000, result.AverageResponseTime, 1e-9)
+}
+
+func Test_MergeResponsesMergesEachStage(t *testing.T) {
+	start := time.Now()
+	createStage := func(target int, transactions int, offset time.Duration) *server.StageResult {
+		return &server.StageResult{
+			Duration:               int64(time.Second),
+			Target:                 int32(target),
+			StartTime:              start.Add(offset).UnixNano(),
+			EndTime:                start.Add(offset + time.Second).UnixNano(),
+			Transactions:           int64(transactions),
+			SuccessfulTransactions: int64(transactions),
+			ResponseTime:           createResponse(transactions, 0, time.Millisecond, start, time.Second).ResponseTime,
+		}
+	}
+	first := createResponse(0, 0, time.Millisecond, start, time.Second)
+	first.Stages = []*server.StageResult{createStage(2, 100, 0)}
+	second := createResponse(0, 0, time.Millisecond, start, time.Second)
+	second.Stages = []*server.StageResult{createStage(1, 50, time.Second)}
+
+	result, err := server.MergeResponses([]*server.SchmokinResponse{first, second})
+
+	assert.Nil(t, err)
+	assert.Len(t, result.Stages, 1)
+	stage := result.Stages[0]
+	assert.Equal(t, 3, stage.Target)
+	assert.Equal(t, 150, stage.Transactions)
+	assert.Equal(t, int64(150), stage.ResponseTime.Count)
+	assert.Equal(t, 2*time.Second, stage.EndTime.Sub(stage.StartTime))
+	assert.Equal(t, float64(75), stage.TransactionRate())
+}
+
+func Test_MergeResponsesMergesTheWarmup(t *testing.T) {
+	start := time.Now()
+	first := createResponse(10, 0, time.Millisecond, start, time.Second)
+	first.Warmup = &server.StageResult{
+		StartTime:    start.UnixNano(),
+		EndTime:      start.Add(time.Second).UnixNano(),
+		Transactions: 3,
+		ResponseTime: createResponse(3, 0, time.Second, start, time.Second).ResponseTime,
+	}
+	second := createResponse(10, 0, time.Millisecond, start, time.Second)
+	second.Warmup = &server.StageResult{
+		StartTime:    start.UnixNano(),
+		EndTime:      start.Add(time.Second).UnixNano(),
+		Transactions: 2,
+		ResponseTime: createResponse(2, 0, time.Second, start, time.Second).ResponseTime,
+	}
+
+	result, err := server.MergeResponses([]*server.SchmokinResponse{first, second})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 20, result.Transactions)
+	assert.Equal(t, 5, result.Warmup.Transactions)
+	assert.Equal(t, int64(5), result.Warmup.ResponseTime.Count)
+	assert.InEpsilon(t, float64(time.Second), result.Warmup.ResponseTime.Mean, 0.001)
+}
+
+func Test_MergeResponsesMergesEachEndpointByName(t *testing.T) {
+	start := time.Now()
+	createEndpoint := func(name string, transactions int, failed int64) *server.EndpointResult {
+		return &server.EndpointResult{
+			Name:                   name,
+			Transactions:           int64(transactions),
+			SuccessfulTransactions: int64(transactions) - failed,
+			FailedTransactions:     failed,
+			ResponseTime:           createResponse(transactions, 0, time.Millisecond, start, time.Second).ResponseTime,
+		}
+	}
+	first := createResponse(30, 0, time.Millisecond, start, 2*time.Second)
+	first.Endpoints = []*server.EndpointResult{createEndpoint("GET /a", 20, 0), createEndpoint("GET /b", 10, 5)}
+	second := createResponse(10, 0, time.Millisecond, start, time.Second)
+	second.Endpoints = []*server.EndpointResult{createEndpoint("GET /b", 10, 0)}
+
+	result, err := server.MergeResponses([]*server.SchmokinResponse{first, second})
+
+	assert.Nil(t, err)
+	assert.Len(t, result.Endpoints, 2)
+	assert.Equal(t, "GET /a", result.Endpoints[0].Name)
+	assert.Equal(t, float64(10), result.Endpoints[0].TransactionRate)
+	endpoint := result.Endpoints[1]
+	assert.Equal(t, "GET /b", endpoint.Name)
+	assert.Equal(t, 20, endpoint.Transactions)
+	assert.Equal(t, 0.25, endpoint.FailureRate())
+	assert.Equal(t, float64(0), endpoint.ErrorRate())
+	assert.Equal(t, int64(20), endpoint.ResponseTime.Count)
+}
+
+func Test_MergeResponsesSumsStatusCodesAndErrorCategories(t *testing.T) {
+	start := time.Now()
+	first := createResponse(10, 2, time.Millisecond, start, time.Second)
+	first.StatusCodes = map[int32]int64{200: 8, 503: 1}
+	first.ErrorCategories = map[string]int64{"Connection Reset": 1}
+	second := createResponse(10, 1, time.Millisecond, start, time.Second)
+	second.StatusCodes = map[int32]int64{200: 9, 503: 1}
+
+	result, err := server.MergeResponses([]*server.SchmokinResponse{first, second})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int64{200: 17, 503: 2}, result.StatusCodes)
+	assert.Equal(t, map[string]int64{"Connection Reset": 1}, result.ErrorCategories)
+}
+
+func Test_MergeResponsesMergesEachIntervalByStartTime(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	createInterval := func(offset time.Duration, transactions int, activeUsers int32, responseTime time.Duration) *server.Interval {
+		return &server.Interval{
+			StartTime:    start.Add(offset).UnixNano(),
+			Duration:     int64(time.Second),
+			Transactions: int64(transactions),
+			ActiveUsers:  activeUsers,
+			ResponseTime: createResponse(transactions, 0, responseTime, start, time.Second).ResponseTime,
+		}
+	}
+	first := createResponse(30, 0, time.Millisecond, start, 2*time.Second)
+	first.Intervals = []*server.Interval{createInterval(0, 10, 2, time.Millisecond), createInterval(time.Second, 20, 2, time.Millisecond)}
+	second := createResponse(30, 0, time.Millisecond, start, 2*time.Second)
+	second.Intervals = []*server.Interval{createInterval(time.Second, 10, 3, 4*time.Millisecond), createInterval(2*time.Second, 20, 3, time.Millisecond)}
+
+	result, err := server.MergeResponses([]*server.SchmokinResponse{second, first})
+
+	assert.Nil(t, err)
+	assert.Len(t, result.Intervals, 3)
+	for index, interval := range result.Intervals {
+		assert.Equal(t, start.Add(time.Duration(index)*time.Second).UnixNano(), interval.StartTime.UnixNano())
+		assert.Equal(t, time.Second, interval.Duration)
+	}
+	merged := result.Intervals[1]
+	assert.Equal(t, 30, merged.Transactions)
+	assert.Equal(t, 5, merged.ActiveUsers)
+	assert.Equal(t, float64(30), merged.TransactionRate())
+	assert.Equal(t, int64(30), merged.ResponseTime.Count)
+	assert.InEpsilon(t, float64(4*time.Millisecond), float64(merged.ResponseTime.P99), 0.001)
+}
+
+func Test_MergeProgressAddsUpTheWorkerProcesses(t *testing.T) {
+	start := time.Now()
+	result, err := server.MergeProgress([]*server.ProgressResponse{
+		{
+			Transactions:       10,
+			FailedTransactions: 1,
+			ActiveUsers:        2,
+			ResponseTime:       createResponse(10, 0, time.Millisecond, start, time.Second).ResponseTime,
+		},
+		{
+			WarmingUp:           true,
+			Transactions:        30,
+			ErroredTransactions: 2,
+			ActiveUsers:         3,
+			ResponseTime:        createResponse(30, 0, 5*time.Millisecond, start, time.Second).ResponseTime,
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.True(t, result.WarmingUp)
+	assert.Equal(t, 40, result.Transactions)
+	assert.Equal(t, int64(1), result.FailedTransactions)
+	assert.Equal(t, int64(2), result.ErroredTransactions)
+	assert.Equal(t, 5, result.ActiveUsers)
+	assert.Equal(t, int64(40), result.ResponseTime.Count)
+	assert.InEpsilon(t, float64(5*time.Millisecond), float64(result.ResponseTime.P95), 0.001)
+}
+
+func Test_MergeResponsesKeepsTheIntervalsOfTheWarmupApart(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	createInterval := func(transactions int, warmup bool) *server.Interval {
+		return &server.Interval{
+			StartTime:    start.UnixNano(),
+			Duration:     int64(time.Second),
+			Transactions: int64(transactions),
+			Warmup:       warmup,
+			ResponseTime: createResponse(transactions, 0, time.Millisecond, start, time.Second).ResponseTime,
+		}
+	}
+	first := createResponse(30, 0, time.Millisecond, start, time.Second)
+	first.Intervals = []*server.Interval{createInterval(10, false), createInterval(5, true)}
+	second := createResponse(30, 0, time.Millisecond, start, time.Second)
+	second.Intervals = []*server.Interval{createInterval(20, false), createInterval(15, true)}
+
+	result, err := server.MergeResponses([]*server.SchmokinResponse{first, second})
+
+	assert.Nil(t, err)
+	assert.Len(t, result.Intervals, 2)
+	assert.True(t, result.Intervals[0].Warmup)
+	assert.Equal(t, 20, result.Intervals[0].Transactions)
+	assert.False(t, result.Intervals[1].Warmup)
+	assert.Equal(t, 30, result.Intervals[1].Transactions)
+}
@@ -1,5 +1,5 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
-// source: schmokin.proto
+// source: surge.proto
 
 package server
 
@@ -103,254 +103,1361 @@ func (m *KillResponse) GetKilled() bool {
 	return false
 }
 
+type InterruptResponse struct {
+	Interrupted          bool     `protobuf:"varint,1,opt,name=interrupted,proto3" json:"interrupted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InterruptResponse) Reset()         { *m = InterruptResponse{} }
+func (m *InterruptResponse) String() string { return proto.CompactTextString(m) }
+func (*InterruptResponse) ProtoMessage()    {}
+func (*InterruptResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{2}
+}
+
+func (m *InterruptResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InterruptResponse.Unmarshal(m, b)
+}
+func (m *InterruptResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InterruptResponse.Marshal(b, m, deterministic)
+}
+func (m *InterruptResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InterruptResponse.Merge(m, src)
+}
+func (m *InterruptResponse) XXX_Size() int {
+	return xxx_messageInfo_InterruptResponse.Size(m)
+}
+func (m *InterruptResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_InterruptResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InterruptResponse proto.InternalMessageInfo
+
+func (m *InterruptResponse) GetInterrupted() bool {
+	if m != nil {
+		return m.Interrupted
+	}
+	return false
+}
+
+type ProgressResponse struct {
+	WarmingUp            bool          `protobuf:"varint,1,opt,name=WarmingUp,proto3" json:"WarmingUp,omitempty"`
+	Transactions         int64         `protobuf:"varint,2,opt,name=Transactions,proto3" json:"Transactions,omitempty"`
+	FailedTransactions   int64         `protobuf:"varint,3,opt,name=FailedTransactions,proto3" json:"FailedTransactions,omitempty"`
+	ErroredTransactions  int64         `protobuf:"varint,4,opt,name=ErroredTransactions,proto3" json:"ErroredTransactions,omitempty"`
+	ActiveUsers          int32         `protobuf:"varint,5,opt,name=ActiveUsers,proto3" json:"ActiveUsers,omitempty"`
+	ResponseTime         *Distribution `protobuf:"bytes,6,opt,name=ResponseTime,proto3" json:"ResponseTime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ProgressResponse) Reset()         { *m = ProgressResponse{} }
+func (m *ProgressResponse) String() string { return proto.CompactTextString(m) }
+func (*ProgressResponse) ProtoMessage()    {}
+func (*ProgressResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{3}
+}
+
+func (m *ProgressResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProgressResponse.Unmarshal(m, b)
+}
+func (m *ProgressResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProgressResponse.Marshal(b, m, deterministic)
+}
+func (m *ProgressResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProgressResponse.Merge(m, src)
+}
+func (m *ProgressResponse) XXX_Size() int {
+	return xxx_messageInfo_ProgressResponse.Size(m)
+}
+func (m *ProgressResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProgressResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProgressResponse proto.InternalMessageInfo
+
+func (m *ProgressResponse) GetWarmingUp() bool {
+	if m != nil {
+		return m.WarmingUp
+	}
+	return false
+}
+
+func (m *ProgressResponse) GetTransactions() int64 {
+	if m != nil {
+		return m.Transactions
+	}
+	return 0
+}
+
+func (m *ProgressResponse) GetFailedTransactions() int64 {
+	if m != nil {
+		return m.FailedTransactions
+	}
+	return 0
+}
+
+func (m *ProgressResponse) GetErroredTransactions() int64 {
+	if m != nil {
+		return m.ErroredTransactions
+	}
+	return 0
+}
+
+func (m *ProgressResponse) GetActiveUsers() int32 {
+	if m != nil {
+		return m.ActiveUsers
+	}
+	return 0
+}
+
+func (m *ProgressResponse) GetResponseTime() *Distribution {
+	if m != nil {
+		return m.ResponseTime
+	}
+	return nil
+}
+
 type SchmokinRequest struct {
 	Lines                []string `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
 	Random               bool     `protobuf:"varint,2,opt,name=random,proto3" json:"random,omitempty"`
 	WorkerCount          int32    `protobuf:"varint,3,opt,name=workerCount,proto3" json:"workerCount,omitempty"`
 	Iterations           int32    `protobuf:"varint,4,opt,name=iterations,proto3" json:"iterations,omitempty"`
+	Headers              []string `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty"`
+	Timeout              int64    `protobuf:"varint,6,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Insecure             bool     `protobuf:"varint,7,opt,name=insecure,proto3" json:"insecure,omitempty"`
+	Cacert               string   `protobuf:"bytes,8,opt,name=cacert,proto3" json:"cacert,omitempty"`
+	Cert                 string   `protobuf:"bytes,9,opt,name=cert,proto3" json:"cert,omitempty"`
+	Key                  string   `protobuf:"bytes,10,opt,name=key,proto3" json:"key,omitempty"`
+	TlsMinVersion        string   `protobuf:"bytes,11,opt,name=tlsMinVersion,proto3" json:"tlsMinVersion,omitempty"`
+	ServerName           string   `protobuf:"bytes,12,opt,name=serverName,proto3" json:"serverName,omitempty"`
+	CookieJar            string   `protobuf:"bytes,13,opt,name=cookieJar,proto3" json:"cookieJar,omitempty"`
+	SaveCookies          bool     `protobuf:"varint,14,opt,name=saveCookies,proto3" json:"saveCookies,omitempty"`
+	Process              int32    `protobuf:"varint,15,opt,name=process,proto3" json:"process,omitempty"`
+	CaptureSize          int64    `protobuf:"varint,16,opt,name=captureSize,proto3" json:"captureSize,omitempty"`
+	Duration             int64    `protobuf:"varint,17,opt,name=duration,proto3" json:"duration,omitempty"`
+	Stages               []*Stage `protobuf:"bytes,18,rep,name=stages,proto3" json:"stages,omitempty"`
+	Rate                 float64  `protobuf:"fixed64,19,opt,name=rate,proto3" json:"rate,omitempty"`
+	MaxWorkers           int32    `protobuf:"varint,20,opt,name=maxWorkers,proto3" json:"maxWorkers,omitempty"`
+	Poisson              bool     `protobuf:"varint,21,opt,name=poisson,proto3" json:"poisson,omitempty"`
+	WarmupDuration       int64    `protobuf:"varint,22,opt,name=warmupDuration,proto3" json:"warmupDuration,omitempty"`
+	WarmupIterations     int32    `protobuf:"varint,23,opt,name=warmupIterations,proto3" json:"warmupIterations,omitempty"`
+	Interval             int64    `protobuf:"varint,24,opt,name=interval,proto3" json:"interval,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SchmokinRequest) Reset()         { *m = SchmokinRequest{} }
+func (m *SchmokinRequest) String() string { return proto.CompactTextString(m) }
+func (*SchmokinRequest) ProtoMessage()    {}
+func (*SchmokinRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{4}
+}
+
+func (m *SchmokinRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SchmokinRequest.Unmarshal(m, b)
+}
+func (m *SchmokinRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SchmokinRequest.Marshal(b, m, deterministic)
+}
+func (m *SchmokinRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SchmokinRequest.Merge(m, src)
+}
+func (m *SchmokinRequest) XXX_Size() int {
+	return xxx_messageInfo_SchmokinRequest.Size(m)
+}
+func (m *SchmokinRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SchmokinRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SchmokinRequest proto.InternalMessageInfo
+
+func (m *SchmokinRequest) GetLines() []string {
+	if m != nil {
+		return m.Lines
+	}
+	return nil
+}
+
+func (m *SchmokinRequest) GetRandom() bool {
+	if m != nil {
+		return m.Random
+	}
+	return false
+}
+
+func (m *SchmokinRequest) GetWorkerCount() int32 {
+	if m != nil {
+		return m.WorkerCount
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetIterations() int32 {
+	if m != nil {
+		return m.Iterations
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetHeaders() []string {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+func (m *SchmokinRequest) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetInsecure() bool {
+	if m != nil {
+		return m.Insecure
+	}
+	return false
+}
+
+func (m *SchmokinRequest) GetCacert() string {
+	if m != nil {
+		return m.Cacert
+	}
+	return ""
+}
+
+func (m *SchmokinRequest) GetCert() string {
+	if m != nil {
+		return m.Cert
+	}
+	return ""
+}
+
+func (m *SchmokinRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *SchmokinRequest) GetTlsMinVersion() string {
+	if m != nil {
+		return m.TlsMinVersion
+	}
+	return ""
+}
+
+func (m *SchmokinRequest) GetServerName() string {
+	if m != nil {
+		return m.ServerName
+	}
+	return ""
+}
+
+func (m *SchmokinRequest) GetCookieJar() string {
+	if m != nil {
+		return m.CookieJar
+	}
+	return ""
+}
+
+func (m *SchmokinRequest) GetSaveCookies() bool {
+	if m != nil {
+		return m.SaveCookies
+	}
+	return false
+}
+
+func (m *SchmokinRequest) GetProcess() int32 {
+	if m != nil {
+		return m.Process
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetCaptureSize() int64 {
+	if m != nil {
+		return m.CaptureSize
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetDuration() int64 {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetStages() []*Stage {
+	if m != nil {
+		return m.Stages
+	}
+	return nil
+}
+
+func (m *SchmokinRequest) GetRate() float64 {
+	if m != nil {
+		return m.Rate
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetMaxWorkers() int32 {
+	if m != nil {
+		return m.MaxWorkers
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetPoisson() bool {
+	if m != nil {
+		return m.Poisson
+	}
+	return false
+}
+
+func (m *SchmokinRequest) GetWarmupDuration() int64 {
+	if m != nil {
+		return m.WarmupDuration
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetWarmupIterations() int32 {
+	if m != nil {
+		return m.WarmupIterations
+	}
+	return 0
+}
+
+func (m *SchmokinRequest) GetInterval() int64 {
+	if m != nil {
+		return m.Interval
+	}
+	return 0
+}
+
+type RunUpdate struct {
+	Progress             *ProgressResponse `protobuf:"bytes,1,opt,name=Progress,proto3" json:"Progress,omitempty"`
+	Result               *SchmokinResponse `protobuf:"bytes,2,opt,name=Result,proto3" json:"Result,omitempty"`
+	Final                bool              `protobuf:"varint,3,opt,name=Final,proto3" json:"Final,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *RunUpdate) Reset()         { *m = RunUpdate{} }
+func (m *RunUpdate) String() string { return proto.CompactTextString(m) }
+func (*RunUpdate) ProtoMessage()    {}
+func (*RunUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{5}
+}
+
+func (m *RunUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunUpdate.Unmarshal(m, b)
+}
+func (m *RunUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunUpdate.Marshal(b, m, deterministic)
+}
+func (m *RunUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunUpdate.Merge(m, src)
+}
+func (m *RunUpdate) XXX_Size() int {
+	return xxx_messageInfo_RunUpdate.Size(m)
+}
+func (m *RunUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunUpdate proto.InternalMessageInfo
+
+func (m *RunUpdate) GetProgress() *ProgressResponse {
+	if m != nil {
+		return m.Progress
+	}
+	return nil
+}
+
+func (m *RunUpdate) GetResult() *SchmokinResponse {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *RunUpdate) GetFinal() bool {
+	if m != nil {
+		return m.Final
+	}
+	return false
+}
+
+type Stage struct {
+	Duration             int64    `protobuf:"varint,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	Target               int32    `protobuf:"varint,2,opt,name=target,proto3" json:"target,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SchmokinRequest) Reset()         { *m = SchmokinRequest{} }
-func (m *SchmokinRequest) String() string { return proto.CompactTextString(m) }
-func (*SchmokinRequest) ProtoMessage()    {}
-func (*SchmokinRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e8d979c7c21201bc, []int{2}
+func (m *Stage) Reset()         { *m = Stage{} }
+func (m *Stage) String() string { return proto.CompactTextString(m) }
+func (*Stage) ProtoMessage()    {}
+func (*Stage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{6}
+}
+
+func (m *Stage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Stage.Unmarshal(m, b)
+}
+func (m *Stage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Stage.Marshal(b, m, deterministic)
+}
+func (m *Stage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Stage.Merge(m, src)
+}
+func (m *Stage) XXX_Size() int {
+	return xxx_messageInfo_Stage.Size(m)
+}
+func (m *Stage) XXX_DiscardUnknown() {
+	xxx_messageInfo_Stage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Stage proto.InternalMessageInfo
+
+func (m *Stage) GetDuration() int64 {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
+func (m *Stage) GetTarget() int32 {
+	if m != nil {
+		return m.Target
+	}
+	return 0
+}
+
+type SchmokinResponse struct {
+	Transactions           int32             `protobuf:"varint,1,opt,name=Transactions,proto3" json:"Transactions,omitempty"`
+	Availability           float64           `protobuf:"fixed64,2,opt,name=Availability,proto3" json:"Availability,omitempty"`
+	ElapsedTime            int64             `protobuf:"varint,3,opt,name=ElapsedTime,proto3" json:"ElapsedTime,omitempty"`
+	AverageResponseTime    float64           `protobuf:"fixed64,4,opt,name=AverageResponseTime,proto3" json:"AverageResponseTime,omitempty"`
+	TotalBytesSent         int64             `protobuf:"varint,5,opt,name=TotalBytesSent,proto3" json:"TotalBytesSent,omitempty"`
+	TotalBytesReceived     int64             `protobuf:"varint,6,opt,name=TotalBytesReceived,proto3" json:"TotalBytesReceived,omitempty"`
+	TransactionRate        float64           `protobuf:"fixed64,7,opt,name=TransactionRate,proto3" json:"TransactionRate,omitempty"`
+	ConcurrencyRate        float64           `protobuf:"fixed64,8,opt,name=ConcurrencyRate,proto3" json:"ConcurrencyRate,omitempty"`
+	DataSendRate           float64           `protobuf:"fixed64,9,opt,name=DataSendRate,proto3" json:"DataSendRate,omitempty"`
+	DataReceiveRate        float64           `protobuf:"fixed64,10,opt,name=DataReceiveRate,proto3" json:"DataReceiveRate,omitempty"`
+	SuccessfulTransactions int64             `protobuf:"varint,11,opt,name=SuccessfulTransactions,proto3" json:"SuccessfulTransactions,omitempty"`
+	FailedTransactions     int64             `protobuf:"varint,12,opt,name=FailedTransactions,proto3" json:"FailedTransactions,omitempty"`
+	LongestTransaction     int64             `protobuf:"varint,13,opt,name=LongestTransaction,proto3" json:"LongestTransaction,omitempty"`
+	ShortestTransaction    int64             `protobuf:"varint,14,opt,name=ShortestTransaction,proto3" json:"ShortestTransaction,omitempty"`
+	TimedOutTransactions   int64             `protobuf:"varint,15,opt,name=TimedOutTransactions,proto3" json:"TimedOutTransactions,omitempty"`
+	DNSLookupTime          *Distribution     `protobuf:"bytes,16,opt,name=DNSLookupTime,proto3" json:"DNSLookupTime,omitempty"`
+	ConnectTime            *Distribution     `protobuf:"bytes,17,opt,name=ConnectTime,proto3" json:"ConnectTime,omitempty"`
+	TLSHandshakeTime       *Distribution     `protobuf:"bytes,18,opt,name=TLSHandshakeTime,proto3" json:"TLSHandshakeTime,omitempty"`
+	FirstByteTime          *Distribution     `protobuf:"bytes,19,opt,name=FirstByteTime,proto3" json:"FirstByteTime,omitempty"`
+	ContentTransferTime    *Distribution     `protobuf:"bytes,20,opt,name=ContentTransferTime,proto3" json:"ContentTransferTime,omitempty"`
+	ResponseTime           *Distribution     `protobuf:"bytes,21,opt,name=ResponseTime,proto3" json:"ResponseTime,omitempty"`
+	StartTime              int64             `protobuf:"varint,22,opt,name=StartTime,proto3" json:"StartTime,omitempty"`
+	EndTime                int64             `protobuf:"varint,23,opt,name=EndTime,proto3" json:"EndTime,omitempty"`
+	Stages                 []*StageResult    `protobuf:"bytes,24,rep,name=Stages,proto3" json:"Stages,omitempty"`
+	DroppedIterations      int64             `protobuf:"varint,25,opt,name=DroppedIterations,proto3" json:"DroppedIterations,omitempty"`
+	LateIterations         int64             `protobuf:"varint,26,opt,name=LateIterations,proto3" json:"LateIterations,omitempty"`
+	CorrectedResponseTime  *Distribution     `protobuf:"bytes,27,opt,name=CorrectedResponseTime,proto3" json:"CorrectedResponseTime,omitempty"`
+	Interrupted            bool              `protobuf:"varint,28,opt,name=Interrupted,proto3" json:"Interrupted,omitempty"`
+	Warmup                 *StageResult      `protobuf:"bytes,29,opt,name=Warmup,proto3" json:"Warmup,omitempty"`
+	Endpoints              []*EndpointResult `protobuf:"bytes,30,rep,name=Endpoints,proto3" json:"Endpoints,omitempty"`
+	StatusCodes            map[int32]int64   `protobuf:"bytes,31,rep,name=StatusCodes,proto3" json:"StatusCodes,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	ErrorCategories        map[string]int64  `protobuf:"bytes,32,rep,name=ErrorCategories,proto3" json:"ErrorCategories,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	ErroredTransactions    int64             `protobuf:"varint,33,opt,name=ErroredTransactions,proto3" json:"ErroredTransactions,omitempty"`
+	Intervals              []*Interval       `protobuf:"bytes,34,rep,name=Intervals,proto3" json:"Intervals,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}          `json:"-"`
+	XXX_unrecognized       []byte            `json:"-"`
+	XXX_sizecache          int32             `json:"-"`
+}
+
+func (m *SchmokinResponse) Reset()         { *m = SchmokinResponse{} }
+func (m *SchmokinResponse) String() string { return proto.CompactTextString(m) }
+func (*SchmokinResponse) ProtoMessage()    {}
+func (*SchmokinResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{7}
+}
+
+func (m *SchmokinResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SchmokinResponse.Unmarshal(m, b)
+}
+func (m *SchmokinResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SchmokinResponse.Marshal(b, m, deterministic)
+}
+func (m *SchmokinResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SchmokinResponse.Merge(m, src)
+}
+func (m *SchmokinResponse) XXX_Size() int {
+	return xxx_messageInfo_SchmokinResponse.Size(m)
+}
+func (m *SchmokinResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SchmokinResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SchmokinResponse proto.InternalMessageInfo
+
+func (m *SchmokinResponse) GetTransactions() int32 {
+	if m != nil {
+		return m.Transactions
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetAvailability() float64 {
+	if m != nil {
+		return m.Availability
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetElapsedTime() int64 {
+	if m != nil {
+		return m.ElapsedTime
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetAverageResponseTime() float64 {
+	if m != nil {
+		return m.AverageResponseTime
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetTotalBytesSent() int64 {
+	if m != nil {
+		return m.TotalBytesSent
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetTotalBytesReceived() int64 {
+	if m != nil {
+		return m.TotalBytesReceived
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetTransactionRate() float64 {
+	if m != nil {
+		return m.TransactionRate
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetConcurrencyRate() float64 {
+	if m != nil {
+		return m.ConcurrencyRate
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetDataSendRate() float64 {
+	if m != nil {
+		return m.DataSendRate
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetDataReceiveRate() float64 {
+	if m != nil {
+		return m.DataReceiveRate
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetSuccessfulTransactions() int64 {
+	if m != nil {
+		return m.SuccessfulTransactions
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetFailedTransactions() int64 {
+	if m != nil {
+		return m.FailedTransactions
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetLongestTransaction() int64 {
+	if m != nil {
+		return m.LongestTransaction
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetShortestTransaction() int64 {
+	if m != nil {
+		return m.ShortestTransaction
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetTimedOutTransactions() int64 {
+	if m != nil {
+		return m.TimedOutTransactions
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetDNSLookupTime() *Distribution {
+	if m != nil {
+		return m.DNSLookupTime
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetConnectTime() *Distribution {
+	if m != nil {
+		return m.ConnectTime
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetTLSHandshakeTime() *Distribution {
+	if m != nil {
+		return m.TLSHandshakeTime
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetFirstByteTime() *Distribution {
+	if m != nil {
+		return m.FirstByteTime
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetContentTransferTime() *Distribution {
+	if m != nil {
+		return m.ContentTransferTime
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetResponseTime() *Distribution {
+	if m != nil {
+		return m.ResponseTime
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetEndTime() int64 {
+	if m != nil {
+		return m.EndTime
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetStages() []*StageResult {
+	if m != nil {
+		return m.Stages
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetDroppedIterations() int64 {
+	if m != nil {
+		return m.DroppedIterations
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetLateIterations() int64 {
+	if m != nil {
+		return m.LateIterations
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetCorrectedResponseTime() *Distribution {
+	if m != nil {
+		return m.CorrectedResponseTime
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetInterrupted() bool {
+	if m != nil {
+		return m.Interrupted
+	}
+	return false
+}
+
+func (m *SchmokinResponse) GetWarmup() *StageResult {
+	if m != nil {
+		return m.Warmup
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetEndpoints() []*EndpointResult {
+	if m != nil {
+		return m.Endpoints
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetStatusCodes() map[int32]int64 {
+	if m != nil {
+		return m.StatusCodes
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetErrorCategories() map[string]int64 {
+	if m != nil {
+		return m.ErrorCategories
+	}
+	return nil
+}
+
+func (m *SchmokinResponse) GetErroredTransactions() int64 {
+	if m != nil {
+		return m.ErroredTransactions
+	}
+	return 0
+}
+
+func (m *SchmokinResponse) GetIntervals() []*Interval {
+	if m != nil {
+		return m.Intervals
+	}
+	return nil
+}
+
+type EndpointResult struct {
+	Name                   string        `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Transactions           int64         `protobuf:"varint,2,opt,name=Transactions,proto3" json:"Transactions,omitempty"`
+	SuccessfulTransactions int64         `protobuf:"varint,3,opt,name=SuccessfulTransactions,proto3" json:"SuccessfulTransactions,omitempty"`
+	FailedTransactions     int64         `protobuf:"varint,4,opt,name=FailedTransactions,proto3" json:"FailedTransactions,omitempty"`
+	TimedOutTransactions   int64         `protobuf:"varint,5,opt,name=TimedOutTransactions,proto3" json:"TimedOutTransactions,omitempty"`
+	TotalBytesSent         int64         `protobuf:"varint,6,opt,name=TotalBytesSent,proto3" json:"TotalBytesSent,omitempty"`
+	TotalBytesReceived     int64         `protobuf:"varint,7,opt,name=TotalBytesReceived,proto3" json:"TotalBytesReceived,omitempty"`
+	ResponseTime           *Distribution `protobuf:"bytes,8,opt,name=ResponseTime,proto3" json:"ResponseTime,omitempty"`
+	ErroredTransactions    int64         `protobuf:"varint,9,opt,name=ErroredTransactions,proto3" json:"ErroredTransactions,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}      `json:"-"`
+	XXX_unrecognized       []byte        `json:"-"`
+	XXX_sizecache          int32         `json:"-"`
+}
+
+func (m *EndpointResult) Reset()         { *m = EndpointResult{} }
+func (m *EndpointResult) String() string { return proto.CompactTextString(m) }
+func (*EndpointResult) ProtoMessage()    {}
+func (*EndpointResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{8}
+}
+
+func (m *EndpointResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EndpointResult.Unmarshal(m, b)
+}
+func (m *EndpointResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EndpointResult.Marshal(b, m, deterministic)
+}
+func (m *EndpointResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndpointResult.Merge(m, src)
+}
+func (m *EndpointResult) XXX_Size() int {
+	return xxx_messageInfo_EndpointResult.Size(m)
+}
+func (m *EndpointResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndpointResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndpointResult proto.InternalMessageInfo
+
+func (m *EndpointResult) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *EndpointResult) GetTransactions() int64 {
+	if m != nil {
+		return m.Transactions
+	}
+	return 0
+}
+
+func (m *EndpointResult) GetSuccessfulTransactions() int64 {
+	if m != nil {
+		return m.SuccessfulTransactions
+	}
+	return 0
+}
+
+func (m *EndpointResult) GetFailedTransactions() int64 {
+	if m != nil {
+		return m.FailedTransactions
+	}
+	return 0
+}
+
+func (m *EndpointResult) GetTimedOutTransactions() int64 {
+	if m != nil {
+		return m.TimedOutTransactions
+	}
+	return 0
+}
+
+func (m *EndpointResult) GetTotalBytesSent() int64 {
+	if m != nil {
+		return m.TotalBytesSent
+	}
+	return 0
 }
 
-func (m *SchmokinRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SchmokinRequest.Unmarshal(m, b)
+func (m *EndpointResult) GetTotalBytesReceived() int64 {
+	if m != nil {
+		return m.TotalBytesReceived
+	}
+	return 0
 }
-func (m *SchmokinRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SchmokinRequest.Marshal(b, m, deterministic)
+
+func (m *EndpointResult) GetResponseTime() *Distribution {
+	if m != nil {
+		return m.ResponseTime
+	}
+	return nil
 }
-func (m *SchmokinRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SchmokinRequest.Merge(m, src)
+
+func (m *EndpointResult) GetErroredTransactions() int64 {
+	if m != nil {
+		return m.ErroredTransactions
+	}
+	return 0
 }
-func (m *SchmokinRequest) XXX_Size() int {
-	return xxx_messageInfo_SchmokinRequest.Size(m)
+
+type StageResult struct {
+	Duration               int64         `protobuf:"varint,1,opt,name=Duration,proto3" json:"Duration,omitempty"`
+	Target                 int32         `protobuf:"varint,2,opt,name=Target,proto3" json:"Target,omitempty"`
+	StartTime              int64         `protobuf:"varint,3,opt,name=StartTime,proto3" json:"StartTime,omitempty"`
+	EndTime                int64         `protobuf:"varint,4,opt,name=EndTime,proto3" json:"EndTime,omitempty"`
+	Transactions           int64         `protobuf:"varint,5,opt,name=Transactions,proto3" json:"Transactions,omitempty"`
+	SuccessfulTransactions int64         `protobuf:"varint,6,opt,name=SuccessfulTransactions,proto3" json:"SuccessfulTransactions,omitempty"`
+	FailedTransactions     int64         `protobuf:"varint,7,opt,name=FailedTransactions,proto3" json:"FailedTransactions,omitempty"`
+	TimedOutTransactions   int64         `protobuf:"varint,8,opt,name=TimedOutTransactions,proto3" json:"TimedOutTransactions,omitempty"`
+	TotalBytesSent         int64         `protobuf:"varint,9,opt,name=TotalBytesSent,proto3" json:"TotalBytesSent,omitempty"`
+	TotalBytesReceived     int64         `protobuf:"varint,10,opt,name=TotalBytesReceived,proto3" json:"TotalBytesReceived,omitempty"`
+	ResponseTime           *Distribution `protobuf:"bytes,11,opt,name=ResponseTime,proto3" json:"ResponseTime,omitempty"`
+	ErroredTransactions    int64         `protobuf:"varint,12,opt,name=ErroredTransactions,proto3" json:"ErroredTransactions,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}      `json:"-"`
+	XXX_unrecognized       []byte        `json:"-"`
+	XXX_sizecache          int32         `json:"-"`
 }
-func (m *SchmokinRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_SchmokinRequest.DiscardUnknown(m)
+
+func (m *StageResult) Reset()         { *m = StageResult{} }
+func (m *StageResult) String() string { return proto.CompactTextString(m) }
+func (*StageResult) ProtoMessage()    {}
+func (*StageResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{9}
 }
 
-var xxx_messageInfo_SchmokinRequest proto.InternalMessageInfo
+func (m *StageResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StageResult.Unmarshal(m, b)
+}
+func (m *StageResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StageResult.Marshal(b, m, deterministic)
+}
+func (m *StageResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StageResult.Merge(m, src)
+}
+func (m *StageResult) XXX_Size() int {
+	return xxx_messageInfo_StageResult.Size(m)
+}
+func (m *StageResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_StageResult.DiscardUnknown(m)
+}
 
-func (m *SchmokinRequest) GetLines() []string {
+var xxx_messageInfo_StageResult proto.InternalMessageInfo
+
+func (m *StageResult) GetDuration() int64 {
 	if m != nil {
-		return m.Lines
+		return m.Duration
 	}
-	return nil
+	return 0
 }
 
-func (m *SchmokinRequest) GetRandom() bool {
+func (m *StageResult) GetTarget() int32 {
 	if m != nil {
-		return m.Random
+		return m.Target
 	}
-	return false
+	return 0
 }
 
-func (m *SchmokinRequest) GetWorkerCount() int32 {
+func (m *StageResult) GetStartTime() int64 {
 	if m != nil {
-		return m.WorkerCount
+		return m.StartTime
 	}
 	return 0
 }
 
-func (m *SchmokinRequest) GetIterations() int32 {
+func (m *StageResult) GetEndTime() int64 {
 	if m != nil {
-		return m.Iterations
+		return m.EndTime
 	}
 	return 0
 }
 
-type SchmokinResponse struct {
-	Transactions           int32    `protobuf:"varint,1,opt,name=Transactions,proto3" json:"Transactions,omitempty"`
-	Availability           float64  `protobuf:"fixed64,2,opt,name=Availability,proto3" json:"Availability,omitempty"`
-	ElapsedTime            int64    `protobuf:"varint,3,opt,name=ElapsedTime,proto3" json:"ElapsedTime,omitempty"`
-	AverageResponseTime    float64  `protobuf:"fixed64,4,opt,name=AverageResponseTime,proto3" json:"AverageResponseTime,omitempty"`
-	TotalBytesSent         int32    `protobuf:"varint,5,opt,name=TotalBytesSent,proto3" json:"TotalBytesSent,omitempty"`
-	TotalBytesReceived     int32    `protobuf:"varint,6,opt,name=TotalBytesReceived,proto3" json:"TotalBytesReceived,omitempty"`
-	TransactionRate        float64  `protobuf:"fixed64,7,opt,name=TransactionRate,proto3" json:"TransactionRate,omitempty"`
-	ConcurrencyRate        float64  `protobuf:"fixed64,8,opt,name=ConcurrencyRate,proto3" json:"ConcurrencyRate,omitempty"`
-	DataSendRate           float64  `protobuf:"fixed64,9,opt,name=DataSendRate,proto3" json:"DataSendRate,omitempty"`
-	DataReceiveRate        float64  `protobuf:"fixed64,10,opt,name=DataReceiveRate,proto3" json:"DataReceiveRate,omitempty"`
-	SuccessfulTransactions int64    `protobuf:"varint,11,opt,name=SuccessfulTransactions,proto3" json:"SuccessfulTransactions,omitempty"`
-	FailedTransactions     int64    `protobuf:"varint,12,opt,name=FailedTransactions,proto3" json:"FailedTransactions,omitempty"`
-	LongestTransaction     int64    `protobuf:"varint,13,opt,name=LongestTransaction,proto3" json:"LongestTransaction,omitempty"`
-	ShortestTransaction    int64    `protobuf:"varint,14,opt,name=ShortestTransaction,proto3" json:"ShortestTransaction,omitempty"`
-	XXX_NoUnkeyedLiteral   struct{} `json:"-"`
-	XXX_unrecognized       []byte   `json:"-"`
-	XXX_sizecache          int32    `json:"-"`
+func (m *StageResult) GetTransactions() int64 {
+	if m != nil {
+		return m.Transactions
+	}
+	return 0
 }
 
-func (m *SchmokinResponse) Reset()         { *m = SchmokinResponse{} }
-func (m *SchmokinResponse) String() string { return proto.CompactTextString(m) }
-func (*SchmokinResponse) ProtoMessage()    {}
-func (*SchmokinResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e8d979c7c21201bc, []int{3}
+func (m *StageResult) GetSuccessfulTransactions() int64 {
+	if m != nil {
+		return m.SuccessfulTransactions
+	}
+	return 0
 }
 
-func (m *SchmokinResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SchmokinResponse.Unmarshal(m, b)
+func (m *StageResult) GetFailedTransactions() int64 {
+	if m != nil {
+		return m.FailedTransactions
+	}
+	return 0
 }
-func (m *SchmokinResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SchmokinResponse.Marshal(b, m, deterministic)
+
+func (m *StageResult) GetTimedOutTransactions() int64 {
+	if m != nil {
+		return m.TimedOutTransactions
+	}
+	return 0
 }
-func (m *SchmokinResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SchmokinResponse.Merge(m, src)
+
+func (m *StageResult) GetTotalBytesSent() int64 {
+	if m != nil {
+		return m.TotalBytesSent
+	}
+	return 0
 }
-func (m *SchmokinResponse) XXX_Size() int {
-	return xxx_messageInfo_SchmokinResponse.Size(m)
+
+func (m *StageResult) GetTotalBytesReceived() int64 {
+	if m != nil {
+		return m.TotalBytesReceived
+	}
+	return 0
 }
-func (m *SchmokinResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_SchmokinResponse.DiscardUnknown(m)
+
+func (m *StageResult) GetResponseTime() *Distribution {
+	if m != nil {
+		return m.ResponseTime
+	}
+	return nil
 }
 
-var xxx_messageInfo_SchmokinResponse proto.InternalMessageInfo
+func (m *StageResult) GetErroredTransactions() int64 {
+	if m != nil {
+		return m.ErroredTransactions
+	}
+	return 0
+}
 
-func (m *SchmokinResponse) GetTransactions() int32 {
+type Interval struct {
+	StartTime              int64         `protobuf:"varint,1,opt,name=StartTime,proto3" json:"StartTime,omitempty"`
+	Duration               int64         `protobuf:"varint,2,opt,name=Duration,proto3" json:"Duration,omitempty"`
+	Transactions           int64         `protobuf:"varint,3,opt,name=Transactions,proto3" json:"Transactions,omitempty"`
+	SuccessfulTransactions int64         `protobuf:"varint,4,opt,name=SuccessfulTransactions,proto3" json:"SuccessfulTransactions,omitempty"`
+	FailedTransactions     int64         `protobuf:"varint,5,opt,name=FailedTransactions,proto3" json:"FailedTransactions,omitempty"`
+	ErroredTransactions    int64         `protobuf:"varint,6,opt,name=ErroredTransactions,proto3" json:"ErroredTransactions,omitempty"`
+	TimedOutTransactions   int64         `protobuf:"varint,7,opt,name=TimedOutTransactions,proto3" json:"TimedOutTransactions,omitempty"`
+	TotalBytesSent         int64         `protobuf:"varint,8,opt,name=TotalBytesSent,proto3" json:"TotalBytesSent,omitempty"`
+	TotalBytesReceived     int64         `protobuf:"varint,9,opt,name=TotalBytesReceived,proto3" json:"TotalBytesReceived,omitempty"`
+	ActiveUsers            int32         `protobuf:"varint,10,opt,name=ActiveUsers,proto3" json:"ActiveUsers,omitempty"`
+	ResponseTime           *Distribution `protobuf:"bytes,11,opt,name=ResponseTime,proto3" json:"ResponseTime,omitempty"`
+	Warmup                 bool          `protobuf:"varint,12,opt,name=Warmup,proto3" json:"Warmup,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}      `json:"-"`
+	XXX_unrecognized       []byte        `json:"-"`
+	XXX_sizecache          int32         `json:"-"`
+}
+
+func (m *Interval) Reset()         { *m = Interval{} }
+func (m *Interval) String() string { return proto.CompactTextString(m) }
+func (*Interval) ProtoMessage()    {}
+func (*Interval) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{10}
+}
+
+func (m *Interval) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Interval.Unmarshal(m, b)
+}
+func (m *Interval) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Interval.Marshal(b, m, deterministic)
+}
+func (m *Interval) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Interval.Merge(m, src)
+}
+func (m *Interval) XXX_Size() int {
+	return xxx_messageInfo_Interval.Size(m)
+}
+func (m *Interval) XXX_DiscardUnknown() {
+	xxx_messageInfo_Interval.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Interval proto.InternalMessageInfo
+
+func (m *Interval) GetStartTime() int64 {
+	if m != nil {
+		return m.StartTime
+	}
+	return 0
+}
+
+func (m *Interval) GetDuration() int64 {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
+func (m *Interval) GetTransactions() int64 {
 	if m != nil {
 		return m.Transactions
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetAvailability() float64 {
+func (m *Interval) GetSuccessfulTransactions() int64 {
 	if m != nil {
-		return m.Availability
+		return m.SuccessfulTransactions
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetElapsedTime() int64 {
+func (m *Interval) GetFailedTransactions() int64 {
 	if m != nil {
-		return m.ElapsedTime
+		return m.FailedTransactions
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetAverageResponseTime() float64 {
+func (m *Interval) GetErroredTransactions() int64 {
 	if m != nil {
-		return m.AverageResponseTime
+		return m.ErroredTransactions
+	}
+	return 0
+}
+
+func (m *Interval) GetTimedOutTransactions() int64 {
+	if m != nil {
+		return m.TimedOutTransactions
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetTotalBytesSent() int32 {
+func (m *Interval) GetTotalBytesSent() int64 {
 	if m != nil {
 		return m.TotalBytesSent
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetTotalBytesReceived() int32 {
+func (m *Interval) GetTotalBytesReceived() int64 {
 	if m != nil {
 		return m.TotalBytesReceived
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetTransactionRate() float64 {
+func (m *Interval) GetActiveUsers() int32 {
 	if m != nil {
-		return m.TransactionRate
+		return m.ActiveUsers
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetConcurrencyRate() float64 {
+func (m *Interval) GetResponseTime() *Distribution {
 	if m != nil {
-		return m.ConcurrencyRate
+		return m.ResponseTime
+	}
+	return nil
+}
+
+func (m *Interval) GetWarmup() bool {
+	if m != nil {
+		return m.Warmup
+	}
+	return false
+}
+
+type Distribution struct {
+	Count                int64    `protobuf:"varint,1,opt,name=Count,proto3" json:"Count,omitempty"`
+	Mean                 float64  `protobuf:"fixed64,2,opt,name=Mean,proto3" json:"Mean,omitempty"`
+	Min                  int64    `protobuf:"varint,3,opt,name=Min,proto3" json:"Min,omitempty"`
+	Max                  int64    `protobuf:"varint,4,opt,name=Max,proto3" json:"Max,omitempty"`
+	StdDev               float64  `protobuf:"fixed64,5,opt,name=StdDev,proto3" json:"StdDev,omitempty"`
+	P50                  int64    `protobuf:"varint,6,opt,name=P50,proto3" json:"P50,omitempty"`
+	P75                  int64    `protobuf:"varint,7,opt,name=P75,proto3" json:"P75,omitempty"`
+	P90                  int64    `protobuf:"varint,8,opt,name=P90,proto3" json:"P90,omitempty"`
+	P95                  int64    `protobuf:"varint,9,opt,name=P95,proto3" json:"P95,omitempty"`
+	P99                  int64    `protobuf:"varint,10,opt,name=P99,proto3" json:"P99,omitempty"`
+	P999                 int64    `protobuf:"varint,11,opt,name=P999,proto3" json:"P999,omitempty"`
+	Histogram            []byte   `protobuf:"bytes,12,opt,name=Histogram,proto3" json:"Histogram,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Distribution) Reset()         { *m = Distribution{} }
+func (m *Distribution) String() string { return proto.CompactTextString(m) }
+func (*Distribution) ProtoMessage()    {}
+func (*Distribution) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e8d979c7c21201bc, []int{11}
+}
+
+func (m *Distribution) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Distribution.Unmarshal(m, b)
+}
+func (m *Distribution) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Distribution.Marshal(b, m, deterministic)
+}
+func (m *Distribution) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Distribution.Merge(m, src)
+}
+func (m *Distribution) XXX_Size() int {
+	return xxx_messageInfo_Distribution.Size(m)
+}
+func (m *Distribution) XXX_DiscardUnknown() {
+	xxx_messageInfo_Distribution.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Distribution proto.InternalMessageInfo
+
+func (m *Distribution) GetCount() int64 {
+	if m != nil {
+		return m.Count
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetDataSendRate() float64 {
+func (m *Distribution) GetMean() float64 {
 	if m != nil {
-		return m.DataSendRate
+		return m.Mean
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetDataReceiveRate() float64 {
+func (m *Distribution) GetMin() int64 {
 	if m != nil {
-		return m.DataReceiveRate
+		return m.Min
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetSuccessfulTransactions() int64 {
+func (m *Distribution) GetMax() int64 {
 	if m != nil {
-		return m.SuccessfulTransactions
+		return m.Max
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetFailedTransactions() int64 {
+func (m *Distribution) GetStdDev() float64 {
 	if m != nil {
-		return m.FailedTransactions
+		return m.StdDev
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetLongestTransaction() int64 {
+func (m *Distribution) GetP50() int64 {
 	if m != nil {
-		return m.LongestTransaction
+		return m.P50
 	}
 	return 0
 }
 
-func (m *SchmokinResponse) GetShortestTransaction() int64 {
+func (m *Distribution) GetP75() int64 {
 	if m != nil {
-		return m.ShortestTransaction
+		return m.P75
+	}
+	return 0
+}
+
+func (m *Distribution) GetP90() int64 {
+	if m != nil {
+		return m.P90
+	}
+	return 0
+}
+
+func (m *Distribution) GetP95() int64 {
+	if m != nil {
+		return m.P95
+	}
+	return 0
+}
+
+func (m *Distribution) GetP99() int64 {
+	if m != nil {
+		return m.P99
+	}
+	return 0
+}
+
+func (m *Distribution) GetP999() int64 {
+	if m != nil {
+		return m.P999
 	}
 	return 0
 }
 
+func (m *Distribution) GetHistogram() []byte {
+	if m != nil {
+		return m.Histogram
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*PingResponse)(nil), "server.PingResponse")
 	proto.RegisterType((*KillResponse)(nil), "server.KillResponse")
+	proto.RegisterType((*InterruptResponse)(nil), "server.InterruptResponse")
+	proto.RegisterType((*ProgressResponse)(nil), "server.ProgressResponse")
 	proto.RegisterType((*SchmokinRequest)(nil), "server.SchmokinRequest")
+	proto.RegisterType((*RunUpdate)(nil), "server.RunUpdate")
+	proto.RegisterType((*Stage)(nil), "server.Stage")
 	proto.RegisterType((*SchmokinResponse)(nil), "server.SchmokinResponse")
+	proto.RegisterMapType((map[string]int64)(nil), "server.SchmokinResponse.ErrorCategoriesEntry")
+	proto.RegisterMapType((map[int32]int64)(nil), "server.SchmokinResponse.StatusCodesEntry")
+	proto.RegisterType((*EndpointResult)(nil), "server.EndpointResult")
+	proto.RegisterType((*StageResult)(nil), "server.StageResult")
+	proto.RegisterType((*Interval)(nil), "server.Interval")
+	proto.RegisterType((*Distribution)(nil), "server.Distribution")
 }
 
-func init() { proto.RegisterFile("schmokin.proto", fileDescriptor_e8d979c7c21201bc) }
+func init() { proto.RegisterFile("surge.proto", fileDescriptor_e8d979c7c21201bc) }
 
 var fileDescriptor_e8d979c7c21201bc = []byte{
-	// 496 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x93, 0xcd, 0x6e, 0x13, 0x31,
-	0x14, 0x85, 0x35, 0xe4, 0xa7, 0xed, 0x4d, 0x5a, 0x24, 0x53, 0x22, 0x2b, 0x48, 0x28, 0xca, 0xa2,
-	0xca, 0x6a, 0x8a, 0x4a, 0xc5, 0xbe, 0x94, 0xb2, 0x81, 0x05, 0xf2, 0xe4, 0x05, 0x9c, 0x99, 0xdb,
-	0xc4, 0xaa, 0x63, 0x07, 0xdb, 0x13, 0x34, 0x1b, 0x1e, 0x89, 0x57, 0xe3, 0x15, 0x90, 0xed, 0x69,
-	0x3b, 0x33, 0x04, 0xb1, 0xbc, 0xe7, 0x7e, 0xc7, 0x39, 0xf1, 0xf8, 0xc0, 0xc8, 0x96, 0x66, 0x8d,
-	0xe9, 0xce, 0x68, 0xa7, 0xc9, 0xd0, 0xa2, 0xd9, 0xa3, 0x99, 0xbe, 0x59, 0x6b, 0xbd, 0x96, 0x78,
-	0x19, 0xd4, 0x55, 0x79, 0x7f, 0x89, 0xdb, 0x9d, 0xab, 0x22, 0x34, 0x5f, 0xc0, 0xf8, 0x9b, 0x50,
-	0x6b, 0x86, 0x76, 0xa7, 0x95, 0x45, 0x42, 0xe1, 0x68, 0x83, 0x5c, 0xba, 0x4d, 0x45, 0x93, 0x59,
-	0xb2, 0x38, 0x66, 0x8f, 0xe3, 0xfc, 0x02, 0xc6, 0x5f, 0x84, 0x94, 0x4f, 0xe4, 0x04, 0x86, 0x0f,
-	0x42, 0x4a, 0x2c, 0x6a, 0xb0, 0x9e, 0xe6, 0x3f, 0x61, 0x9c, 0xf9, 0x14, 0x0c, 0xbf, 0x97, 0x68,
-	0x1d, 0x39, 0x87, 0x81, 0x14, 0x0a, 0x2d, 0x4d, 0x66, 0xbd, 0xc5, 0x09, 0x8b, 0x83, 0x77, 0x1b,
-	0xae, 0x0a, 0xbd, 0xa5, 0x2f, 0xa2, 0x3b, 0x4e, 0x64, 0x06, 0xa3, 0x1f, 0xda, 0x3c, 0xa0, 0xb9,
-	0xd5, 0xa5, 0x72, 0xb4, 0x37, 0x4b, 0x16, 0x03, 0xd6, 0x94, 0xc8, 0x5b, 0x00, 0xe1, 0xd0, 0x70,
-	0x27, 0xb4, 0xb2, 0xb4, 0x1f, 0x80, 0x86, 0x32, 0xff, 0xdd, 0x87, 0xd3, 0x3a, 0x40, 0x9d, 0x74,
-	0x0e, 0xe3, 0xa5, 0xe1, 0xca, 0xf2, 0x3c, 0x7a, 0x92, 0xe0, 0x69, 0x69, 0x9e, 0xb9, 0xd9, 0x73,
-	0x21, 0xf9, 0x4a, 0x48, 0xe1, 0xaa, 0x90, 0x2a, 0x61, 0x2d, 0xcd, 0x67, 0xbb, 0x93, 0x7c, 0x67,
-	0xb1, 0x58, 0x8a, 0x2d, 0x86, 0x6c, 0x3d, 0xd6, 0x94, 0xc8, 0x3b, 0x78, 0x75, 0xb3, 0x47, 0xc3,
-	0x9f, 0x7f, 0x3c, 0x90, 0xfd, 0x70, 0xd8, 0xa1, 0x15, 0xb9, 0x80, 0xb3, 0xa5, 0x76, 0x5c, 0x7e,
-	0xac, 0x1c, 0xda, 0x0c, 0x95, 0xa3, 0x83, 0x90, 0xae, 0xa3, 0x92, 0x14, 0xc8, 0xb3, 0xc2, 0x30,
-	0x47, 0xb1, 0xc7, 0x82, 0x0e, 0x03, 0x7b, 0x60, 0x43, 0x16, 0xf0, 0xb2, 0xf1, 0xff, 0x18, 0x77,
-	0x48, 0x8f, 0x42, 0x8a, 0xae, 0xec, 0xc9, 0x5b, 0xad, 0xf2, 0xd2, 0x18, 0x54, 0x79, 0x15, 0xc8,
-	0xe3, 0x48, 0x76, 0x64, 0x7f, 0x47, 0x9f, 0xb8, 0xe3, 0x19, 0xaa, 0x22, 0x60, 0x27, 0xf1, 0x8e,
-	0x9a, 0x9a, 0x3f, 0xcd, 0xcf, 0x75, 0x8e, 0x80, 0x41, 0x3c, 0xad, 0x23, 0x93, 0x0f, 0x30, 0xc9,
-	0xca, 0x3c, 0x47, 0x6b, 0xef, 0x4b, 0xd9, 0xfa, 0x3e, 0xa3, 0x70, 0xb1, 0xff, 0xd8, 0xfa, 0x9b,
-	0xf8, 0xcc, 0x85, 0xc4, 0xa2, 0xe5, 0x19, 0x07, 0xcf, 0x81, 0x8d, 0xe7, 0xbf, 0x6a, 0xb5, 0x46,
-	0xeb, 0x1a, 0x32, 0x3d, 0x8d, 0xfc, 0xdf, 0x1b, 0xff, 0x0d, 0xb3, 0x8d, 0x36, 0xae, 0x63, 0x38,
-	0x0b, 0x86, 0x43, 0xab, 0xab, 0x5f, 0x49, 0xfd, 0xe4, 0x33, 0x34, 0x7b, 0x91, 0x23, 0xb9, 0x82,
-	0x1e, 0x2b, 0x15, 0x39, 0x4f, 0x63, 0x03, 0xd3, 0x66, 0x1f, 0xa6, 0xaf, 0x3b, 0x6a, 0xfd, 0x48,
-	0xaf, 0xa1, 0xef, 0x8b, 0x48, 0x26, 0x69, 0xac, 0x6b, 0xfa, 0x58, 0xd7, 0xf4, 0xce, 0xd7, 0x75,
-	0xfa, 0x74, 0x58, 0xab, 0xae, 0xd7, 0xd0, 0xf7, 0xa5, 0xfc, 0xbf, 0xab, 0x59, 0xdd, 0xd5, 0x30,
-	0x50, 0xef, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0x17, 0xf6, 0xbb, 0xc3, 0x2f, 0x04, 0x00, 0x00,
+	// 1652 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0x5f, 0x73, 0xdb, 0x36,
+	0x12, 0x1f, 0x5a, 0x7f, 0x2c, 0x41, 0xb2, 0x2d, 0xc3, 0x8e, 0xc3, 0x38, 0xb9, 0x9c, 0x4e, 0x73,
+	0x97, 0xd1, 0xfd, 0x53, 0x3c, 0x3e, 0x3b, 0x17, 0xe7, 0x66, 0x32, 0x97, 0x48, 0xf6, 0xc4, 0x89,
+	0x93, 0x7a, 0x28, 0xa7, 0x7e, 0x86, 0x49, 0x44, 0xe6, 0x98, 0x02, 0x54, 0x00, 0x54, 0xe2, 0x3e,
+	0x75, 0xfa, 0xd6, 0xd7, 0x7e, 0x8a, 0x3e, 0xf7, 0xa3, 0xf4, 0xd3, 0xf4, 0xa5, 0x33, 0x1d, 0x2c,
+	0x48, 0x89, 0xa4, 0x45, 0xc5, 0x4a, 0xdf, 0xb0, 0x3f, 0xec, 0x82, 0x58, 0xfc, 0x16, 0xbb, 0x4b,
+	0xa0, 0x9a, 0x0c, 0xc5, 0x80, 0x76, 0x46, 0x82, 0x2b, 0x8e, 0xcb, 0x92, 0x8a, 0x31, 0x15, 0xdb,
+	0xf7, 0x07, 0x9c, 0x0f, 0x02, 0xfa, 0x18, 0xd0, 0x8b, 0xf0, 0xc3, 0x63, 0x3a, 0x1c, 0xa9, 0x6b,
+	0xa3, 0xd4, 0x6a, 0xa3, 0xfa, 0xa9, 0xcf, 0x06, 0x0e, 0x95, 0x23, 0xce, 0x24, 0xc5, 0x36, 0x5a,
+	0xbe, 0xa4, 0x24, 0x50, 0x97, 0xd7, 0xb6, 0xd5, 0xb4, 0xda, 0x15, 0x27, 0x16, 0x5b, 0x8f, 0x50,
+	0xfd, 0x8d, 0x1f, 0x04, 0x13, 0xcd, 0x2d, 0x54, 0xbe, 0xf2, 0x83, 0x80, 0x7a, 0x91, 0x62, 0x24,
+	0xb5, 0xf6, 0xd1, 0xfa, 0x31, 0x53, 0x54, 0x88, 0x70, 0xa4, 0x26, 0xca, 0x4d, 0x54, 0xf3, 0x63,
+	0x70, 0x62, 0x91, 0x84, 0x5a, 0x3f, 0x2e, 0xa1, 0xc6, 0xa9, 0xe0, 0x03, 0x41, 0xa5, 0x9c, 0x98,
+	0x3d, 0x40, 0xd5, 0x73, 0x22, 0x86, 0x3e, 0x1b, 0xbc, 0x1f, 0x45, 0x46, 0x53, 0x00, 0xb7, 0x50,
+	0xfd, 0x4c, 0x10, 0x26, 0x89, 0xab, 0x7c, 0xce, 0xa4, 0xbd, 0xd4, 0xb4, 0xda, 0x05, 0x27, 0x85,
+	0xe1, 0x0e, 0xc2, 0x47, 0xc4, 0x0f, 0xa8, 0x97, 0xd2, 0x2c, 0x80, 0xe6, 0x8c, 0x19, 0xbc, 0x83,
+	0x36, 0x0e, 0x85, 0xe0, 0x22, 0x63, 0x50, 0x04, 0x83, 0x59, 0x53, 0xda, 0xb5, 0x17, 0xae, 0xf2,
+	0xc7, 0xf4, 0xbd, 0xa4, 0x42, 0xda, 0xa5, 0xa6, 0xd5, 0x2e, 0x39, 0x49, 0x08, 0x3f, 0x45, 0xf5,
+	0xd8, 0xa3, 0x33, 0x7f, 0x48, 0xed, 0x72, 0xd3, 0x6a, 0xd7, 0x76, 0x37, 0x3b, 0x86, 0x9f, 0x4e,
+	0xcf, 0x97, 0x4a, 0xf8, 0x17, 0xa1, 0x5e, 0xce, 0x49, 0x69, 0xb6, 0x7e, 0x29, 0xa1, 0xb5, 0xbe,
+	0x7b, 0x39, 0xe4, 0x57, 0x3e, 0x73, 0xe8, 0x37, 0x21, 0x95, 0x0a, 0x6f, 0xa2, 0x52, 0xe0, 0x33,
+	0x2a, 0x6d, 0xab, 0x59, 0x68, 0x57, 0x1d, 0x23, 0x68, 0x36, 0x04, 0x61, 0x1e, 0x1f, 0xc2, 0x29,
+	0x54, 0x9c, 0x48, 0xd2, 0xbb, 0xfb, 0xc8, 0xc5, 0x15, 0x15, 0x5d, 0x1e, 0x32, 0x05, 0x8e, 0x97,
+	0x9c, 0x24, 0x84, 0x1f, 0x22, 0xe4, 0x2b, 0x2a, 0xc8, 0xd4, 0xd1, 0x92, 0x93, 0x40, 0xa2, 0x88,
+	0xf0, 0x8c, 0x6f, 0xfa, 0x8b, 0xb1, 0xa8, 0x67, 0x94, 0x3f, 0xa4, 0x3c, 0x54, 0xe0, 0x52, 0xc1,
+	0x89, 0x45, 0xbc, 0x8d, 0x2a, 0x3e, 0x93, 0xd4, 0x0d, 0x05, 0xb5, 0x97, 0x61, 0x3f, 0x13, 0x59,
+	0xef, 0xd4, 0x25, 0x2e, 0x15, 0xca, 0xae, 0x34, 0xad, 0x76, 0xd5, 0x89, 0x24, 0x8c, 0x51, 0x11,
+	0xd0, 0x2a, 0xa0, 0x30, 0xc6, 0x0d, 0x54, 0xb8, 0xa2, 0xd7, 0x36, 0x02, 0x48, 0x0f, 0xf1, 0x5f,
+	0xd1, 0x8a, 0x0a, 0xe4, 0x5b, 0x9f, 0x7d, 0x4d, 0x85, 0xf4, 0x39, 0xb3, 0x6b, 0x30, 0x97, 0x06,
+	0xb5, 0x4f, 0xe6, 0x70, 0xdf, 0x91, 0x21, 0xb5, 0xeb, 0xa0, 0x92, 0x40, 0x74, 0x5c, 0xb9, 0x9c,
+	0x5f, 0xf9, 0xf4, 0x35, 0x11, 0xf6, 0x0a, 0x4c, 0x4f, 0x01, 0x7d, 0x66, 0x92, 0x8c, 0x69, 0x17,
+	0x00, 0x69, 0xaf, 0x9a, 0x60, 0x4d, 0x40, 0xda, 0xf3, 0x91, 0xe0, 0x2e, 0x95, 0xd2, 0x5e, 0x83,
+	0x03, 0x8b, 0x45, 0x6d, 0xeb, 0x92, 0x91, 0x0a, 0x05, 0xed, 0xfb, 0xdf, 0x52, 0xbb, 0x01, 0xe7,
+	0x92, 0x84, 0xf4, 0xd9, 0x78, 0xa1, 0x39, 0x5c, 0x7b, 0x1d, 0xa6, 0x27, 0x32, 0xfe, 0x1b, 0x2a,
+	0x4b, 0x45, 0x06, 0x54, 0xda, 0xb8, 0x59, 0x68, 0xd7, 0x76, 0x57, 0xe2, 0x18, 0xe9, 0x6b, 0xd4,
+	0x89, 0x26, 0xf5, 0x51, 0x09, 0xa2, 0xa8, 0xbd, 0xd1, 0xb4, 0xda, 0x96, 0x03, 0x63, 0xed, 0xf2,
+	0x90, 0x7c, 0x3a, 0x07, 0x62, 0xa5, 0xbd, 0x69, 0x68, 0x9c, 0x22, 0xb0, 0x65, 0xee, 0x4b, 0xc9,
+	0x99, 0x7d, 0xc7, 0x5c, 0xec, 0x48, 0xc4, 0x8f, 0xd0, 0xea, 0x47, 0x22, 0x86, 0xe1, 0xa8, 0x17,
+	0x6f, 0x6b, 0x0b, 0xb6, 0x95, 0x41, 0xf1, 0x3f, 0x50, 0xc3, 0x20, 0xc7, 0xd3, 0x70, 0xb9, 0x0b,
+	0xdf, 0xb9, 0x81, 0x9b, 0x00, 0x50, 0x54, 0x8c, 0x49, 0x60, 0xdb, 0xc6, 0xc9, 0x58, 0x6e, 0xfd,
+	0x60, 0xa1, 0xaa, 0x13, 0xb2, 0xf7, 0x23, 0x4f, 0xef, 0x7b, 0x0f, 0x55, 0xe2, 0x6b, 0x0f, 0x37,
+	0xbc, 0xb6, 0x6b, 0xc7, 0x4e, 0x67, 0xd3, 0x81, 0x33, 0xd1, 0xc4, 0x3b, 0xa8, 0xec, 0x50, 0x19,
+	0x06, 0xca, 0x5e, 0x4a, 0xdb, 0x4c, 0x6f, 0x4b, 0x64, 0x13, 0xe9, 0xe9, 0x6b, 0x73, 0xe4, 0x33,
+	0x12, 0xc0, 0x15, 0xa8, 0x38, 0x46, 0x68, 0xfd, 0x0f, 0x95, 0xe0, 0x68, 0x53, 0xac, 0x58, 0x19,
+	0x56, 0xb6, 0x50, 0x59, 0x11, 0x31, 0xa0, 0xe6, 0x63, 0x25, 0x27, 0x92, 0x5a, 0x3f, 0xaf, 0xa2,
+	0x46, 0xf6, 0x7b, 0x37, 0x92, 0x92, 0x05, 0x26, 0x29, 0x4c, 0xeb, 0xbc, 0x18, 0x13, 0x3f, 0x20,
+	0x17, 0x7e, 0xe0, 0xab, 0x6b, 0x58, 0xd6, 0x72, 0x52, 0x98, 0x0e, 0xa4, 0xc3, 0x80, 0x8c, 0x24,
+	0xf5, 0x20, 0x67, 0x98, 0x8c, 0x95, 0x84, 0x74, 0xaa, 0x7a, 0x31, 0xa6, 0x42, 0x07, 0x46, 0x32,
+	0xbb, 0x14, 0x61, 0xb1, 0x59, 0x53, 0x9a, 0xe9, 0x33, 0xae, 0x48, 0xf0, 0xf2, 0x5a, 0x51, 0xd9,
+	0xa7, 0x4c, 0x41, 0xb6, 0x2a, 0x38, 0x19, 0x54, 0x27, 0xcd, 0x29, 0xe2, 0x50, 0x97, 0xfa, 0x63,
+	0xea, 0x45, 0x77, 0x7c, 0xc6, 0x0c, 0x6e, 0xa3, 0xb5, 0x84, 0x7f, 0x0e, 0x51, 0xe6, 0xd6, 0x5b,
+	0x4e, 0x16, 0xd6, 0x9a, 0x5d, 0xce, 0xdc, 0x50, 0x08, 0xca, 0xdc, 0x6b, 0xd0, 0xac, 0x18, 0xcd,
+	0x0c, 0xac, 0xcf, 0xa8, 0x47, 0x14, 0xe9, 0x53, 0xe6, 0x81, 0x5a, 0xd5, 0x9c, 0x51, 0x12, 0xd3,
+	0xab, 0x69, 0x39, 0xda, 0x07, 0xa8, 0x21, 0xb3, 0x5a, 0x06, 0xc6, 0x4f, 0xd0, 0x56, 0x3f, 0x74,
+	0xf5, 0x0d, 0xfd, 0x10, 0x06, 0x29, 0x7e, 0x6a, 0xe0, 0x55, 0xce, 0x6c, 0x4e, 0xf9, 0xa8, 0xe7,
+	0x96, 0x8f, 0x0e, 0xc2, 0x27, 0x9c, 0x0d, 0xa8, 0x54, 0x09, 0x18, 0x32, 0x4c, 0xc1, 0x99, 0x31,
+	0xa3, 0x39, 0xec, 0x5f, 0x72, 0xa1, 0x32, 0x06, 0xab, 0xa6, 0xdc, 0xcc, 0x98, 0xc2, 0xbb, 0x68,
+	0x53, 0x73, 0xe9, 0x7d, 0x15, 0xaa, 0xd4, 0x9e, 0xd6, 0xc0, 0x64, 0xe6, 0x1c, 0x7e, 0x86, 0x56,
+	0x7a, 0xef, 0xfa, 0x27, 0x9c, 0x5f, 0x85, 0x23, 0x88, 0x91, 0xc6, 0x9c, 0x0a, 0x94, 0x56, 0xc5,
+	0x4f, 0x50, 0xad, 0xcb, 0x19, 0xa3, 0xae, 0x02, 0xcb, 0xf5, 0x39, 0x96, 0x49, 0x45, 0xfc, 0x7f,
+	0xd4, 0x38, 0x3b, 0xe9, 0xbf, 0x22, 0xcc, 0x93, 0x97, 0xe4, 0xca, 0x84, 0x26, 0x9e, 0x63, 0x7c,
+	0x43, 0x5b, 0xef, 0xfa, 0xc8, 0x17, 0x52, 0xe9, 0x58, 0x03, 0xf3, 0x8d, 0x79, 0xbb, 0x4e, 0xa9,
+	0xe2, 0x23, 0xb4, 0xd1, 0xe5, 0x4c, 0x51, 0x66, 0x0e, 0xe2, 0x03, 0x15, 0xb0, 0xc2, 0xe6, 0x9c,
+	0x15, 0x66, 0x19, 0xdc, 0x28, 0xdd, 0x77, 0x6e, 0x5b, 0xba, 0x75, 0x89, 0xe9, 0x2b, 0x22, 0xcc,
+	0xa9, 0x99, 0x84, 0x3a, 0x05, 0x74, 0x36, 0x3e, 0x64, 0xe6, 0x66, 0xdf, 0x35, 0xa5, 0x33, 0x12,
+	0xf1, 0x3f, 0x51, 0xb9, 0x6f, 0x4a, 0x80, 0x0d, 0x25, 0x60, 0x23, 0x5d, 0x02, 0x20, 0x99, 0x39,
+	0x91, 0x0a, 0xfe, 0x17, 0x5a, 0xef, 0x09, 0x3e, 0x1a, 0x51, 0x2f, 0x91, 0x93, 0xef, 0xc1, 0x82,
+	0x37, 0x27, 0xf4, 0xf5, 0x3f, 0x21, 0x8a, 0x26, 0x54, 0xb7, 0xcd, 0xf5, 0x4f, 0xa3, 0xf8, 0x35,
+	0xba, 0xd3, 0xe5, 0x42, 0x50, 0x57, 0x51, 0x2f, 0xe5, 0xfd, 0xfd, 0x39, 0xde, 0xcf, 0x36, 0xd1,
+	0x69, 0xec, 0x38, 0xd1, 0xf8, 0x3d, 0x30, 0xb5, 0x34, 0x01, 0x69, 0x87, 0xcf, 0xa1, 0x7c, 0xd8,
+	0x7f, 0x6a, 0x5a, 0xb9, 0x0e, 0x1b, 0x15, 0xbc, 0x87, 0xaa, 0x87, 0xcc, 0x1b, 0x71, 0x9f, 0x29,
+	0x69, 0x3f, 0x84, 0x03, 0xda, 0x8a, 0xf5, 0xe3, 0x89, 0xc8, 0x64, 0xaa, 0x88, 0xdf, 0xa0, 0x5a,
+	0x5f, 0x11, 0x15, 0xca, 0x2e, 0xf7, 0xa8, 0xb4, 0xff, 0x0c, 0x76, 0x7f, 0xcf, 0x2b, 0x19, 0x9d,
+	0x84, 0xee, 0x21, 0x53, 0xe2, 0xda, 0x49, 0x5a, 0xe3, 0x73, 0xb4, 0x06, 0x6d, 0x60, 0x97, 0x28,
+	0x3a, 0xe0, 0x42, 0x77, 0x08, 0x4d, 0x58, 0xf0, 0xdf, 0xb9, 0x0b, 0x66, 0xf4, 0xcd, 0xa2, 0xd9,
+	0x55, 0xf2, 0x5a, 0xcf, 0xbf, 0xe4, 0xb7, 0x9e, 0x1d, 0x54, 0x3d, 0x8e, 0xaa, 0xaa, 0xb4, 0x5b,
+	0xb0, 0x89, 0x46, 0xbc, 0x89, 0x78, 0xc2, 0x99, 0xaa, 0x6c, 0x3f, 0x47, 0x8d, 0xac, 0x6f, 0x71,
+	0x8b, 0x65, 0xca, 0x94, 0x1e, 0xea, 0x4a, 0x39, 0x26, 0x41, 0x48, 0xa3, 0x7e, 0xda, 0x08, 0xcf,
+	0x96, 0x9e, 0x5a, 0xdb, 0x2f, 0xd1, 0xe6, 0x2c, 0x57, 0x92, 0x6b, 0x54, 0x3f, 0xb3, 0x46, 0xeb,
+	0xa7, 0x02, 0x5a, 0x4d, 0x33, 0xa5, 0xdb, 0x19, 0xe8, 0xd3, 0x8c, 0x3d, 0x8c, 0x6f, 0xd5, 0xdb,
+	0xe7, 0x27, 0xf5, 0xc2, 0x17, 0x24, 0xf5, 0x62, 0x6e, 0x52, 0xcf, 0x4b, 0xb9, 0xa5, 0x39, 0x29,
+	0xf7, 0x66, 0xa9, 0x2d, 0x2f, 0x50, 0x6a, 0x97, 0x73, 0x4b, 0x6d, 0x36, 0x21, 0x55, 0x6e, 0x9d,
+	0x90, 0x72, 0xc2, 0xab, 0x9a, 0x1b, 0x5e, 0xad, 0xdf, 0x0a, 0xa8, 0x96, 0xb8, 0x84, 0xba, 0x47,
+	0xea, 0x65, 0x7a, 0xa4, 0x5e, 0xa2, 0x47, 0x3a, 0x4b, 0xf5, 0x48, 0x46, 0x4a, 0xa7, 0xc1, 0xc2,
+	0x9c, 0x34, 0x58, 0x4c, 0xa7, 0xc1, 0x2c, 0xff, 0xa5, 0x85, 0xf8, 0x2f, 0x7f, 0x01, 0xff, 0xcb,
+	0x0b, 0xf3, 0x5f, 0x59, 0x88, 0xff, 0xea, 0x02, 0xfc, 0xa3, 0x5b, 0xf3, 0x5f, 0xfb, 0xa3, 0xfc,
+	0xd7, 0xf3, 0xf9, 0xff, 0xbe, 0x88, 0x2a, 0x71, 0xf2, 0x48, 0x13, 0x69, 0x65, 0x89, 0x4c, 0x86,
+	0xc6, 0x52, 0x26, 0x34, 0xb2, 0x54, 0x16, 0x16, 0xa2, 0xb2, 0xf8, 0x05, 0x54, 0x96, 0x16, 0xfd,
+	0xbd, 0x2f, 0xe7, 0xe7, 0xd8, 0x3c, 0xf2, 0x97, 0x17, 0x22, 0xbf, 0xb2, 0x00, 0xf9, 0xd5, 0x5c,
+	0xf2, 0x33, 0x4f, 0x0d, 0xe8, 0xf3, 0x4f, 0x0d, 0xb7, 0x0f, 0x8f, 0xad, 0x49, 0x19, 0xae, 0x9b,
+	0x07, 0x04, 0x23, 0xb5, 0xbe, 0x5b, 0x42, 0xf5, 0xa4, 0x99, 0x4e, 0xed, 0xe6, 0x2d, 0xc1, 0x04,
+	0x81, 0x11, 0x74, 0x0e, 0x7f, 0x4b, 0x09, 0x8b, 0x7e, 0x65, 0x60, 0xac, 0xcb, 0xc2, 0x5b, 0x9f,
+	0x45, 0x7c, 0xeb, 0x21, 0x20, 0xe4, 0x53, 0xc4, 0xa9, 0x1e, 0xea, 0xcf, 0xf6, 0x95, 0xd7, 0xa3,
+	0x63, 0x20, 0xcd, 0x72, 0x22, 0x49, 0x6b, 0x9e, 0xee, 0xef, 0x44, 0xc4, 0xe8, 0x21, 0x20, 0xff,
+	0xdd, 0x8f, 0xce, 0x5d, 0x0f, 0x01, 0x39, 0xd8, 0x89, 0xce, 0x56, 0x0f, 0x0d, 0xb2, 0x1f, 0x9d,
+	0xa0, 0x1e, 0x1a, 0xe4, 0x20, 0xba, 0x50, 0x7a, 0xa8, 0x77, 0x7a, 0x7a, 0x70, 0x70, 0x10, 0x35,
+	0xfe, 0x30, 0xd6, 0xc1, 0xfd, 0xca, 0x97, 0x8a, 0x0f, 0x04, 0x19, 0x82, 0xff, 0x75, 0x67, 0x0a,
+	0xec, 0xfe, 0x6a, 0x4d, 0x5f, 0x61, 0xfa, 0x54, 0x8c, 0x7d, 0x97, 0xe2, 0x03, 0xf8, 0x87, 0xed,
+	0x2b, 0x41, 0xc9, 0x10, 0xdf, 0xbd, 0x59, 0xf9, 0xe1, 0xad, 0x66, 0x7b, 0x3d, 0x9e, 0x98, 0xfc,
+	0xef, 0xee, 0x58, 0x78, 0x0f, 0x15, 0xf5, 0x93, 0x1b, 0xde, 0xea, 0x98, 0x87, 0xb9, 0x4e, 0xfc,
+	0x30, 0xd7, 0x39, 0xd4, 0x0f, 0x73, 0xdb, 0x13, 0xb6, 0x52, 0x0f, 0x73, 0x7b, 0xa8, 0xa8, 0x9f,
+	0xdf, 0x3e, 0x6f, 0x95, 0x7a, 0xa4, 0x7b, 0x8e, 0xaa, 0x93, 0x5e, 0x2b, 0xd7, 0xf4, 0x5e, 0xaa,
+	0x67, 0x48, 0xbe, 0xdb, 0x5d, 0x94, 0x41, 0xf5, 0x3f, 0xbf, 0x0f, 0x00, 0x0c, 0xbc, 0xf0, 0x72,
+	0x59, 0x14, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -365,9 +1472,10 @@ const _ = grpc.SupportPackageIsVersion4
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type SchmokinServiceClient interface {
-	Run(ctx context.Context, in *SchmokinRequest, opts ...grpc.CallOption) (*SchmokinResponse, error)
+	RunStream(ctx context.Context, in *SchmokinRequest, opts ...grpc.CallOption) (SchmokinService_RunStreamClient, error)
 	Ping(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*PingResponse, error)
 	Kill(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*KillResponse, error)
+	Interrupt(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*InterruptResponse, error)
 }
 
 type schmokinServiceClient struct {
@@ -378,13 +1486,36 @@ func NewSchmokinServiceClient(cc *grpc.ClientConn) SchmokinServiceClient {
 	return &schmokinServiceClient{cc}
 }
 
-func (c *schmokinServiceClient) Run(ctx context.Context, in *SchmokinRequest, opts ...grpc.CallOption) (*SchmokinResponse, error) {
-	out := new(SchmokinResponse)
-	err := c.cc.Invoke(ctx, "/server.SchmokinService/Run", in, out, opts...)
+func (c *schmokinServiceClient) RunStream(ctx context.Context, in *SchmokinRequest, opts ...grpc.CallOption) (SchmokinService_RunStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_SchmokinService_serviceDesc.Streams[0], "/server.SchmokinService/RunStream", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	x := &schmokinServiceRunStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SchmokinService_RunStreamClient interface {
+	Recv() (*RunUpdate, error)
+	grpc.ClientStream
+}
+
+type schmokinServiceRunStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *schmokinServiceRunStreamClient) Recv() (*RunUpdate, error) {
+	m := new(RunUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 func (c *schmokinServiceClient) Ping(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*PingResponse, error) {
@@ -405,19 +1536,29 @@ func (c *schmokinServiceClient) Kill(ctx context.Context, in *empty.Empty, opts
 	return out, nil
 }
 
+func (c *schmokinServiceClient) Interrupt(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*InterruptResponse, error) {
+	out := new(InterruptResponse)
+	err := c.cc.Invoke(ctx, "/server.SchmokinService/Interrupt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SchmokinServiceServer is the server API for SchmokinService service.
 type SchmokinServiceServer interface {
-	Run(context.Context, *SchmokinRequest) (*SchmokinResponse, error)
+	RunStream(*SchmokinRequest, SchmokinService_RunStreamServer) error
 	Ping(context.Context, *empty.Empty) (*PingResponse, error)
 	Kill(context.Context, *empty.Empty) (*KillResponse, error)
+	Interrupt(context.Context, *empty.Empty) (*InterruptResponse, error)
 }
 
 // UnimplementedSchmokinServiceServer can be embedded to have forward compatible implementations.
 type UnimplementedSchmokinServiceServer struct {
 }
 
-func (*UnimplementedSchmokinServiceServer) Run(ctx context.Context, req *SchmokinRequest) (*SchmokinResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Run not implemented")
+func (*UnimplementedSchmokinServiceServer) RunStream(req *SchmokinRequest, srv SchmokinService_RunStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method RunStream not implemented")
 }
 func (*UnimplementedSchmokinServiceServer) Ping(ctx context.Context, req *empty.Empty) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
@@ -425,61 +1566,85 @@ func (*UnimplementedSchmokinServiceServer) Ping(ctx context.Context, req *empty.
 func (*UnimplementedSchmokinServiceServer) Kill(ctx context.Context, req *empty.Empty) (*KillResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Kill not implemented")
 }
+func (*UnimplementedSchmokinServiceServer) Interrupt(ctx context.Context, req *empty.Empty) (*InterruptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Interrupt not implemented")
+}
 
 func RegisterSchmokinServiceServer(s *grpc.Server, srv SchmokinServiceServer) {
 	s.RegisterService(&_SchmokinService_serviceDesc, srv)
 }
 
-func _SchmokinService_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SchmokinRequest)
+func _SchmokinService_RunStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SchmokinRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SchmokinServiceServer).RunStream(m, &schmokinServiceRunStreamServer{stream})
+}
+
+type SchmokinService_RunStreamServer interface {
+	Send(*RunUpdate) error
+	grpc.ServerStream
+}
+
+type schmokinServiceRunStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *schmokinServiceRunStreamServer) Send(m *RunUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _SchmokinService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(SchmokinServiceServer).Run(ctx, in)
+		return srv.(SchmokinServiceServer).Ping(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/server.SchmokinService/Run",
+		FullMethod: "/server.SchmokinService/Ping",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SchmokinServiceServer).Run(ctx, req.(*SchmokinRequest))
+		return srv.(SchmokinServiceServer).Ping(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _SchmokinService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _SchmokinService_Kill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(empty.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(SchmokinServiceServer).Ping(ctx, in)
+		return srv.(SchmokinServiceServer).Kill(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/server.SchmokinService/Ping",
+		FullMethod: "/server.SchmokinService/Kill",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SchmokinServiceServer).Ping(ctx, req.(*empty.Empty))
+		return srv.(SchmokinServiceServer).Kill(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _SchmokinService_Kill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _SchmokinService_Interrupt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(empty.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(SchmokinServiceServer).Kill(ctx, in)
+		return srv.(SchmokinServiceServer).Interrupt(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/server.SchmokinService/Kill",
+		FullMethod: "/server.SchmokinService/Interrupt",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(SchmokinServiceServer).Kill(ctx, req.(*empty.Empty))
+		return srv.(SchmokinServiceServer).Interrupt(ctx, req.(*empty.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -488,10 +1653,6 @@ var _SchmokinService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "server.SchmokinService",
 	HandlerType: (*SchmokinServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Run",
-			Handler:    _SchmokinService_Run_Handler,
-		},
 		{
 			MethodName: "Ping",
 			Handler:    _SchmokinService_Ping_Handler,
@@ -500,7 +1661,17 @@ var _SchmokinService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Kill",
 			Handler:    _SchmokinService_Kill_Handler,
 		},
+		{
+			MethodName: "Interrupt",
+			Handler:    _SchmokinService_Interrupt_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunStream",
+			Handler:       _SchmokinService_RunStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "schmokin.proto",
+	Metadata: "surge.proto",
 }
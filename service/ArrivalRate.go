@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// lateThreshold is how long after its scheduled time an iteration can
+// start before it is counted as late.
+const lateThreshold = 10 * time.Millisecond
+
+// arrival is an iteration scheduled by the arrival rate executor.
+type arrival struct {
+	scheduled time.Time
+	line      int
+}
+
+// rateAt returns the arrival rate, in iterations per second, the given time
+// into the run along with the stage running then. With stages the rate ramps
+// linearly from the starting rate to the target of each stage in turn, the
+// stage is -1 when there are no stages or they have all finished.
+func (schmokin *SchmokinService) rateAt(elapsed time.Duration) (float64, int) {
+	if len(schmokin.stages) == 0 {
+		return schmokin.rate, -1
+	}
+	from := schmokin.rate
+	for index, stage := range schmokin.stages {
+		if elapsed < stage.Duration {
+			progress := float64(elapsed) / float64(stage.Duration)
+			return from + (float64(stage.Target)-from)*progress, index
+		}
+		elapsed -= stage.Duration
+		from = float64(stage.Target)
+	}
+	return from, -1
+}
+
+// interarrival returns the time until the next iteration, exponentially
+// distributed around the mean when the arrivals follow a Poisson process.
+func (schmokin *SchmokinService) interarrival(rate float64) time.Duration {
+	mean := float64(time.Second) / rate
+	if schmokin.poisson {
+		return time.Duration(rand.ExpFloat64() * mean)
+	}
+	return time.Duration(mean)
+}
+
+// sleepUntil waits until the given time, returning false if the run ends
+// or is interrupted first.
+func (schmokin *SchmokinService) sleepUntil(ctx context.Context, at time.Time) bool {
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-schmokin.interrupt:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// SplitMaxWorkers returns the share of the most virtual users started to
+// keep up with the rate which one of several processes may start, the
+// remainder going to the first processes.
+func SplitMaxWorkers(maxWorkers int, process int, processes int) int {
+	split := maxWorkers / processes
+	if process < maxWorkers%processes {
+		split++
+	}
+	return split
+}
+
+func (schmokin *SchmokinService) maxUsers() int {
+	if schmokin.maxWorkers < schmokin.workerCount {
+		return schmokin.workerCount
+	}
+	return schmokin.maxWorkers
+}
+
+// addIdleUsers counts the virtual users waiting for an iteration, only
+// iterations which an idle virtual user is waiting for are sent to them.
+func (schmokin *SchmokinService) addIdleUsers(count int) {
+	schmokin.lock.Lock()
+	schmokin.idleUsers += count
+	schmokin.lock.Unlock()
+}
+
+// takeIdleUser claims an idle virtual user to send an iteration to.
+func (schmokin *SchmokinService) takeIdleUser() bool {
+	schmokin.lock.Lock()
+	defer schmokin.lock.Unlock()
+	if schmokin.idleUsers == 0 {
+		return false
+	}
+	schmokin.idleUsers--
+	return true
+}
+
+// arrivalWorker makes the iterations handed to it until there are no more,
+// starting with first when it was started to make one. Virtual users
+// started without an iteration are counted as idle before they start.
+func (schmokin *SchmokinService) arrivalWorker(ctx context.Context, user int, lines []string, first *arrival, arrivals <-chan arrival) {
+	jar := schmokin.newCookieJar()
+	next := first
+	for {
+		if next == nil {
+			scheduled, ok := <-arrivals
+			if !ok {
+				break
+			}
+			next = &scheduled
+		}
+		if time.Since(next.scheduled) > lateThreshold {
+			schmokin.lock.Lock()
+			schmokin.lateIterations++
+			schmokin.lock.Unlock()
+		}
+		if !schmokin.transact(ctx, jar, lines[next.line], next.scheduled) {
+			break
+		}
+		next = nil
+		schmokin.addIdleUsers(1)
+	}
+	if schmokin.saveCookies {
+		schmokin.saveCookieJar(user, jar)
+	}
+	schmokin.waitGroup.Done()
+}
+
+// runArrivals starts iterations at the arrival rate rather than each
+// virtual user starting its next iteration when the last one finishes, so
+// a slow server does not slow the load down. Each iteration goes to an idle
+// virtual user, more are started up to the maximum when none are idle and
+// the iteration is dropped once there are no more to start.
+func (schmokin *SchmokinService) runArrivals(ctx context.Context, lines []string, limit int) {
+	arrivals := make(chan arrival)
+	defer close(arrivals)
+	startUser := func(first *arrival) {
+		if first == nil {
+			schmokin.addIdleUsers(1)
+		}
+		schmokin.waitGroup.Add(1)
+		go schmokin.arrivalWorker(ctx, schmokin.users, lines, first, arrivals)
+		schmokin.users++
+	}
+	for schmokin.users < schmokin.workerCount {
+		startUser(nil)
+	}
+
+	defer schmokin.startStage(-1)
+	start := time.Now()
+	next := start
+	stage := -1
+	for iteration := 0; limit == 0 || iteration < limit; {
+		rate, index := schmokin.rateAt(next.Sub(start))
+		if len(schmokin.stages) > 0 && index < 0 {
+			schmokin.sleepUntil(ctx, start.Add(stagesDuration(schmokin.stages)))
+			return
+		}
+		if !schmokin.sleepUntil(ctx, next) {
+			return
+		}
+		if index != stage {
+			schmokin.startStage(index)
+			stage = index
+		}
+		if rate <= 0 {
+			next = next.Add(stageResolution)
+			continue
+		}
+		scheduled := arrival{scheduled: next, line: iteration % len(lines)}
+		if schmokin.takeIdleUser() {
+			arrivals <- scheduled
+		} else if schmokin.users < schmokin.maxUsers() {
+			startUser(&scheduled)
+		} else {
+			schmokin.lock.Lock()
+			schmokin.droppedIterations++
+			schmokin.lock.Unlock()
+		}
+		iteration++
+		next = next.Add(schmokin.interarrival(rate))
+	}
+}
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func createDelayedServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(delay):
+		}
+	}))
+}
+
+func Test_SchmokinServiceStartsIterationsAtTheRate(t *testing.T) {
+	server := createDelayedServer(0)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetRate(50).
+		SetDuration(500 * time.Millisecond).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.InDelta(t, 25, result.Transactions, 3)
+	assert.Equal(t, int64(0), result.DroppedIterations)
+}
+
+func Test_SplitMaxWorkersSharesTheVirtualUsersBetweenProcesses(t *testing.T) {
+	assert.Equal(t, 4, service.SplitMaxWorkers(10, 0, 3))
+	assert.Equal(t, 3, service.SplitMaxWorkers(10, 1, 3))
+	assert.Equal(t, 3, service.SplitMaxWorkers(10, 2, 3))
+}
+
+func Test_SchmokinServiceKeepsTheRateWhenTheServerIsSlow(t *testing.T) {
+	server := createDelayedServer(100 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetRate(50).
+		SetMaxWorkers(20).
+		SetDuration(500 * time.Millisecond).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	// Iterations started in the last 100ms are still in flight at the end
+	assert.InDelta(t, 20, result.Transactions, 3)
+	assert.Equal(t, int64(0), result.DroppedIterations)
+}
+
+func Test_SchmokinServiceDropsIterationsWhenEveryWorkerIsBusy(t *testing.T) {
+	server := createDelayedServer(time.Second)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetRate(20).
+		SetWorkers(1).
+		SetMaxWorkers(2).
+		SetDuration(500 * time.Millisecond).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Equal(t, 0, result.Transactions)
+	assert.InDelta(t, 8, result.DroppedIterations, 2)
+}
+
+func Test_SchmokinServiceRampsTheRateThroughTheStages(t *testing.T) {
+	server := createDelayedServer(0)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetRate(20).
+		SetStages([]service.Stage{
+			{Duration: 500 * time.Millisecond, Target: 20},
+			{Duration: 500 * time.Millisecond, Target: 100},
+		}).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Len(t, result.Stages, 2)
+	assert.InDelta(t, 10, result.Stages[0].Transactions, 2)
+	assert.InDelta(t, 30, result.Stages[1].Transactions, 4)
+}
+
+func Test_SchmokinServiceStartsIterationsAsAPoissonProcess(t *testing.T) {
+	server := createDelayedServer(0)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetRate(200).
+		SetMaxWorkers(50).
+		SetPoisson(true).
+		SetDuration(time.Second).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.InDelta(t, 200, result.Transactions, 60)
+}
+
+func Test_SchmokinServiceReportsTheCorrectedResponseTimeOfScheduledIterations(t *testing.T) {
+	server := createDelayedServer(50 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetRate(20).
+		SetWorkers(1).
+		SetMaxWorkers(2).
+		SetIterations(2).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Equal(t, int64(2), result.ResponseTime.Count)
+	assert.Equal(t, int64(2), result.CorrectedResponseTime.Count)
+	assert.True(t, result.CorrectedResponseTime.Min >= result.ResponseTime.Min)
+	assert.True(t, result.CorrectedResponseTime.Max >= result.ResponseTime.Max)
+}
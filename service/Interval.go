@@ -0,0 +1,107 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
+)
+
+// DefaultInterval is how long each interval of the time series lasts
+// unless another is set.
+const DefaultInterval = time.Second
+
+// Interval holds the metrics of the transactions which finished during one
+// interval of the run. Intervals start at whole multiples of their duration
+// so those recorded by several processes line up and can be merged.
+type Interval struct {
+	StartTime              time.Time     `json:"start_time"`
+	Duration               time.Duration `json:"duration_ns"`
+	Transactions           int           `json:"transactions"`
+	SuccessfulTransactions int64         `json:"successful_transactions"`
+	FailedTransactions     int64         `json:"failed_transactions"`
+	ErroredTransactions    int64         `json:"errored_transactions"`
+	TimedOutTransactions   int64         `json:"timed_out_transactions"`
+	TotalBytesSent         int           `json:"total_bytes_sent"`
+	TotalBytesReceived     int           `json:"total_bytes_received"`
+	// ActiveUsers is the most virtual users with a transaction in flight
+	// at once during the interval.
+	ActiveUsers  int          `json:"active_users"`
+	ResponseTime Distribution `json:"response_time"`
+	// Warmup is set for the intervals of the warm-up, which may start at
+	// the same time as the first interval of the run.
+	Warmup bool `json:"warmup"`
+}
+
+// TransactionRate returns the transactions per second which finished
+// during the interval.
+func (interval Interval) TransactionRate() float64 {
+	seconds := interval.Duration.Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(interval.Transactions) / seconds
+}
+
+// intervalStats collects the metrics of one interval.
+type intervalStats struct {
+	*stats
+	activeUsers int
+	warmup      bool
+}
+
+// recordInterval records the result in the interval it finished during
+// along with how many virtual users had a transaction in flight, the
+// intervals of the warm-up are kept apart from those of the run.
+func (schmokin *SchmokinService) recordInterval(result schmokinHTTP.Result, activeUsers int) {
+	intervals := schmokin.intervals
+	if schmokin.warmingUp {
+		intervals = schmokin.warmupIntervals
+	}
+	startTime := time.Now().Truncate(schmokin.interval)
+	interval, ok := intervals[startTime.UnixNano()]
+	if !ok {
+		interval = &intervalStats{stats: newStats(), warmup: schmokin.warmingUp}
+		interval.startTime = startTime
+		intervals[startTime.UnixNano()] = interval
+	}
+	interval.record(result)
+	if activeUsers > interval.activeUsers {
+		interval.activeUsers = activeUsers
+	}
+}
+
+// intervalResults returns the results of each interval from the given
+// time ordered by time, the warm-up's first when both start at once.
+// Intervals in which no transactions finished are left out.
+func (schmokin *SchmokinService) intervalResults(from time.Time) []Interval {
+	results := []Interval{}
+	for _, intervals := range []map[int64]*intervalStats{schmokin.warmupIntervals, schmokin.intervals} {
+		for _, interval := range intervals {
+			if interval.startTime.Before(from) {
+				continue
+			}
+			results = append(results, Interval{
+				StartTime:              interval.startTime,
+				Duration:               schmokin.interval,
+				Transactions:           interval.transactions,
+				SuccessfulTransactions: interval.successful,
+				FailedTransactions:     interval.failed,
+				ErroredTransactions:    interval.errored,
+				TimedOutTransactions:   interval.timedOut,
+				TotalBytesSent:         interval.totalBytesSent,
+				TotalBytesReceived:     interval.totalBytesReceived,
+				ActiveUsers:            interval.activeUsers,
+				ResponseTime:           copyDistribution(interval.responseTime),
+				Warmup:                 interval.warmup,
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].StartTime.Equal(results[j].StartTime) {
+			return results[i].Warmup && !results[j].Warmup
+		}
+		return results[i].StartTime.Before(results[j].StartTime)
+	})
+	return results
+}
@@ -0,0 +1,31 @@
+package service
+
+// Progress is a snapshot of a run in progress.
+type Progress struct {
+	WarmingUp           bool
+	Transactions        int
+	FailedTransactions  int64
+	ErroredTransactions int64
+	// ActiveUsers is how many virtual users have a transaction in flight.
+	ActiveUsers int
+	// ResponseTime holds the response times so far, its histogram lets
+	// the response times since an earlier snapshot be worked out.
+	ResponseTime Distribution
+}
+
+// Progress returns a snapshot of the run so far, the transactions made
+// during the warm-up are left out as they are from the result.
+func (schmokin *SchmokinService) Progress() Progress {
+	schmokin.lock.Lock()
+	progress := Progress{
+		WarmingUp:           schmokin.warmingUp,
+		Transactions:        schmokin.transactions,
+		FailedTransactions:  int64(schmokin.failures),
+		ErroredTransactions: int64(schmokin.errors),
+		ActiveUsers:         int(schmokin.concurrencyCounter.Count()),
+		ResponseTime:        copyDistribution(schmokin.responseTime),
+	}
+	schmokin.lock.Unlock()
+	progress.ResponseTime = summarised(progress.ResponseTime)
+	return progress
+}
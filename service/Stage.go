@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stageResolution is how often the number of virtual users is adjusted
+// while a stage ramps up or down.
+const stageResolution = 100 * time.Millisecond
+
+// Stage ramps the number of virtual users linearly from the target of the
+// previous stage, or zero for the first, to its own target over its duration.
+type Stage struct {
+	Duration time.Duration `json:"duration_ns"`
+	Target   int           `json:"target"`
+}
+
+// ParseStages parses stages written as duration:target e.g. 30s:10.
+func ParseStages(values []string) ([]Stage, error) {
+	stages := []Stage{}
+	for _, value := range values {
+		index := strings.LastIndex(value, ":")
+		if index < 0 {
+			return nil, fmt.Errorf("invalid stage %q: expected duration:target e.g. 30s:10", value)
+		}
+		duration, err := time.ParseDuration(value[:index])
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("invalid stage %q: invalid duration %q", value, value[:index])
+		}
+		target, err := strconv.Atoi(value[index+1:])
+		if err != nil || target < 0 {
+			return nil, fmt.Errorf("invalid stage %q: invalid target %q", value, value[index+1:])
+		}
+		stages = append(stages, Stage{Duration: duration, Target: target})
+	}
+	return stages, nil
+}
+
+// SplitStages returns the share of the virtual users of each stage run by
+// one of several processes, the remainder going to the first processes.
+func SplitStages(stages []Stage, process int, processes int) []Stage {
+	split := make([]Stage, len(stages))
+	for index, stage := range stages {
+		split[index] = Stage{
+			Duration: stage.Duration,
+			Target:   stage.Target / processes,
+		}
+		if process < stage.Target%processes {
+			split[index].Target++
+		}
+	}
+	return split
+}
+
+// stagesDuration returns how long it takes to run every stage.
+func stagesDuration(stages []Stage) (duration time.Duration) {
+	for _, stage := range stages {
+		duration += stage.Duration
+	}
+	return
+}
+
+// stoppedUser reports whether a virtual user should stop, either because
+// the run has ended or been interrupted or because the user has been
+// retired.
+func (schmokin *SchmokinService) stoppedUser(ctx context.Context, retire <-chan struct{}) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-schmokin.interrupt:
+		return true
+	case <-retire:
+		return true
+	default:
+		return false
+	}
+}
+
+func (schmokin *SchmokinService) startStage(index int) {
+	schmokin.lock.Lock()
+	defer schmokin.lock.Unlock()
+	now := time.Now()
+	if schmokin.stage >= 0 {
+		schmokin.stageStats[schmokin.stage].endTime = now
+	}
+	schmokin.stage = index
+	if index >= 0 {
+		schmokin.stageStats[index].startTime = now
+	}
+}
+
+// runStages adds and retires virtual users to follow the stages, returning
+// once every stage has run. Retired virtual users finish the transaction
+// they are making before they stop.
+func (schmokin *SchmokinService) runStages(ctx context.Context, lines []string, transactions int) {
+	var users []chan struct{}
+	scale := func(target int) {
+		for len(users) < target {
+			retire := make(chan struct{})
+			schmokin.waitGroup.Add(1)
+			go schmokin.worker(ctx, schmokin.users, lines, transactions, retire)
+			schmokin.users++
+			users = append(users, retire)
+		}
+		for len(users) > target {
+			close(users[len(users)-1])
+			users = users[:len(users)-1]
+		}
+	}
+
+	defer schmokin.startStage(-1)
+	from := 0
+	for index, stage := range schmokin.stages {
+		schmokin.startStage(index)
+		start := time.Now()
+		for elapsed := time.Duration(0); elapsed < stage.Duration; elapsed = time.Since(start) {
+			progress := float64(elapsed) / float64(stage.Duration)
+			scale(from + int(math.Round(float64(stage.Target-from)*progress)))
+			wait := stage.Duration - elapsed
+			if wait > stageResolution {
+				wait = stageResolution
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-schmokin.interrupt:
+				return
+			case <-time.After(wait):
+			}
+		}
+		scale(stage.Target)
+		from = stage.Target
+	}
+}
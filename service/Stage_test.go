@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/service"
+	"github.com/stretchr/testify/assert"
+)
+
+type ParseStagesTestCase struct {
+	Values         []string
+	ExpectedStages []service.Stage
+}
+
+func Test_ParseStages(t *testing.T) {
+	cases := []ParseStagesTestCase{
+		{Values: []string{}, ExpectedStages: []service.Stage{}},
+		{Values: []string{"30s:10"}, ExpectedStages: []service.Stage{{Duration: 30 * time.Second, Target: 10}}},
+		{Values: []string{"30s:10", "5m:100", "30s:0"}, ExpectedStages: []service.Stage{
+			{Duration: 30 * time.Second, Target: 10},
+			{Duration: 5 * time.Minute, Target: 100},
+			{Duration: 30 * time.Second, Target: 0},
+		}},
+	}
+
+	for _, testCase := range cases {
+		stages, err := service.ParseStages(testCase.Values)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.ExpectedStages, stages)
+	}
+}
+
+func Test_ParseStagesReturnsAnErrorForInvalidStages(t *testing.T) {
+	for _, value := range []string{"30s", "abc:10", "30s:ten", "30s:-1", "-30s:10"} {
+		_, err := service.ParseStages([]string{value})
+		assert.NotNil(t, err, value)
+	}
+}
+
+func Test_SplitStagesSharesTheVirtualUsersBetweenProcesses(t *testing.T) {
+	stages := []service.Stage{
+		{Duration: time.Minute, Target: 10},
+		{Duration: time.Minute, Target: 1},
+	}
+
+	assert.Equal(t, []service.Stage{{Duration: time.Minute, Target: 4}, {Duration: time.Minute, Target: 1}}, service.SplitStages(stages, 0, 3))
+	assert.Equal(t, []service.Stage{{Duration: time.Minute, Target: 3}, {Duration: time.Minute, Target: 0}}, service.SplitStages(stages, 1, 3))
+	assert.Equal(t, []service.Stage{{Duration: time.Minute, Target: 3}, {Duration: time.Minute, Target: 0}}, service.SplitStages(stages, 2, 3))
+}
+
+func Test_SchmokinServiceFollowsTheStages(t *testing.T) {
+	var concurrent, peak int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt64(&concurrent, 1)
+		defer atomic.AddInt64(&concurrent, -1)
+		for {
+			previous := atomic.LoadInt64(&peak)
+			if current <= previous || atomic.CompareAndSwapInt64(&peak, previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+	stages := []service.Stage{
+		{Duration: 200 * time.Millisecond, Target: 3},
+		{Duration: 300 * time.Millisecond, Target: 3},
+		{Duration: 200 * time.Millisecond, Target: 0},
+	}
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetStages(stages).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Equal(t, int64(3), atomic.LoadInt64(&peak))
+	assert.Len(t, result.Stages, 3)
+	total := 0
+	for index, stage := range result.Stages {
+		assert.Equal(t, stages[index].Duration, stage.Duration)
+		assert.Equal(t, stages[index].Target, stage.Target)
+		assert.True(t, stage.Transactions > 0, "stage %d transactions %v", index, stage.Transactions)
+		assert.Equal(t, int64(stage.Transactions), stage.ResponseTime.Count)
+		assert.InDelta(t, float64(stage.Duration), float64(stage.EndTime.Sub(stage.StartTime)), float64(50*time.Millisecond))
+		total += stage.Transactions
+	}
+	assert.Equal(t, result.Transactions, total)
+	assert.True(t, result.Stages[1].Transactions > result.Stages[0].Transactions)
+	assert.True(t, result.ElapsedTime < time.Second, "elapsed %v", result.ElapsedTime)
+}
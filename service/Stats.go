@@ -0,0 +1,88 @@
+package service
+
+import (
+	"time"
+
+	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
+	"github.com/reaandrew/schmokin/utils"
+)
+
+// stats collects the metrics of a group of transactions, such as those
+// started during one stage, to report alongside the overall metrics.
+type stats struct {
+	startTime          time.Time
+	endTime            time.Time
+	transactions       int
+	successful         int64
+	failed             int64
+	errored            int64
+	timedOut           int64
+	totalBytesSent     int
+	totalBytesReceived int
+	responseTime       *utils.Histogram
+}
+
+func newStats() *stats {
+	return &stats{
+		responseTime: utils.NewHistogram(),
+	}
+}
+
+// result returns the metrics collected as the result of a stage, with a
+// copy of the response times to be summarised.
+func (stats *stats) result(duration time.Duration, target int) StageResult {
+	return StageResult{
+		Duration:               duration,
+		Target:                 target,
+		StartTime:              stats.startTime,
+		EndTime:                stats.endTime,
+		Transactions:           stats.transactions,
+		SuccessfulTransactions: stats.successful,
+		FailedTransactions:     stats.failed,
+		ErroredTransactions:    stats.errored,
+		TimedOutTransactions:   stats.timedOut,
+		TotalBytesSent:         stats.totalBytesSent,
+		TotalBytesReceived:     stats.totalBytesReceived,
+		ResponseTime:           copyDistribution(stats.responseTime),
+	}
+}
+
+// endpointResult returns the metrics collected as the result of the
+// endpoint with the name, over the elapsed time of the run.
+func (stats *stats) endpointResult(name string, elapsed time.Duration) EndpointResult {
+	result := EndpointResult{
+		Name:                   name,
+		Transactions:           stats.transactions,
+		SuccessfulTransactions: stats.successful,
+		FailedTransactions:     stats.failed,
+		ErroredTransactions:    stats.errored,
+		TimedOutTransactions:   stats.timedOut,
+		TotalBytesSent:         stats.totalBytesSent,
+		TotalBytesReceived:     stats.totalBytesReceived,
+		ResponseTime:           copyDistribution(stats.responseTime),
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		result.TransactionRate = float64(stats.transactions) / seconds
+	}
+	return result
+}
+
+func (stats *stats) record(result schmokinHTTP.Result) {
+	switch {
+	case result.IsError():
+		stats.errored++
+		if result.TimedOut {
+			stats.timedOut++
+		}
+	case result.IsFailure():
+		stats.failed++
+	default:
+		stats.successful++
+	}
+	stats.transactions++
+	stats.totalBytesSent += result.TotalBytesSent
+	stats.totalBytesReceived += result.TotalBytesReceived
+	if result.ResponseTime > 0 {
+		stats.responseTime.Record(int64(result.ResponseTime))
+	}
+}
@@ -1,20 +1,216 @@
 package service
 
-import "time"
+import (
+	"time"
 
+	"github.com/reaandrew/schmokin/utils"
+)
+
+// Distribution summarises the durations, in nanoseconds, recorded
+// for the transactions or one phase of them. The histogram is kept so
+// the distributions of several workers can be merged exactly.
+type Distribution struct {
+	Count     int64            `json:"count"`
+	Mean      float64          `json:"mean_ns"`
+	Min       int64            `json:"min_ns"`
+	Max       int64            `json:"max_ns"`
+	StdDev    float64          `json:"std_dev_ns"`
+	P50       int64            `json:"p50_ns"`
+	P75       int64            `json:"p75_ns"`
+	P90       int64            `json:"p90_ns"`
+	P95       int64            `json:"p95_ns"`
+	P99       int64            `json:"p99_ns"`
+	P999      int64            `json:"p999_ns"`
+	Histogram *utils.Histogram `json:"-"`
+}
+
+func NewDistribution(histogram *utils.Histogram) Distribution {
+	return Distribution{
+		Count:     histogram.Count(),
+		Mean:      histogram.Mean(),
+		Min:       histogram.Min(),
+		Max:       histogram.Max(),
+		StdDev:    histogram.StdDev(),
+		P50:       histogram.ValueAtPercentile(50),
+		P75:       histogram.ValueAtPercentile(75),
+		P90:       histogram.ValueAtPercentile(90),
+		P95:       histogram.ValueAtPercentile(95),
+		P99:       histogram.ValueAtPercentile(99),
+		P999:      histogram.ValueAtPercentile(99.9),
+		Histogram: histogram,
+	}
+}
+
+// copyDistribution returns a distribution holding only a copy of the
+// histogram, for it to be worked out by summarise without holding up the
+// transactions recording to the histogram.
+func copyDistribution(histogram *utils.Histogram) Distribution {
+	return Distribution{Histogram: histogram.Copy()}
+}
+
+// summarised returns the distribution worked out from its histogram.
+func summarised(distribution Distribution) Distribution {
+	if distribution.Histogram == nil {
+		return distribution
+	}
+	return NewDistribution(distribution.Histogram)
+}
+
+// StageResult holds the metrics of the transactions started during one stage.
+type StageResult struct {
+	Duration               time.Duration `json:"duration_ns"`
+	Target                 int           `json:"target"`
+	StartTime              time.Time     `json:"start_time"`
+	EndTime                time.Time     `json:"end_time"`
+	Transactions           int           `json:"transactions"`
+	SuccessfulTransactions int64         `json:"successful_transactions"`
+	FailedTransactions     int64         `json:"failed_transactions"`
+	ErroredTransactions    int64         `json:"errored_transactions"`
+	TimedOutTransactions   int64         `json:"timed_out_transactions"`
+	TotalBytesSent         int           `json:"total_bytes_sent"`
+	TotalBytesReceived     int           `json:"total_bytes_received"`
+	ResponseTime           Distribution  `json:"response_time"`
+}
+
+// outcomeRate returns the fraction of the transactions with an outcome.
+func outcomeRate(count int64, transactions int) float64 {
+	if transactions == 0 {
+		return 0
+	}
+	return float64(count) / float64(transactions)
+}
+
+// Availability returns the fraction of the transactions during the stage
+// which got a response, whether or not it was successful.
+func (stage StageResult) Availability() float64 {
+	return 1 - outcomeRate(stage.ErroredTransactions, stage.Transactions)
+}
+
+// SuccessRate returns the fraction of the transactions during the stage
+// which were successful.
+func (stage StageResult) SuccessRate() float64 {
+	return outcomeRate(stage.SuccessfulTransactions, stage.Transactions)
+}
+
+// TransactionRate returns the transactions per second made during the stage.
+func (stage StageResult) TransactionRate() float64 {
+	seconds := stage.EndTime.Sub(stage.StartTime).Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(stage.Transactions) / seconds
+}
+
+// EndpointResult holds the metrics of the transactions made to one
+// endpoint, named after the method and path or by the line.
+type EndpointResult struct {
+	Name                   string       `json:"name"`
+	Transactions           int          `json:"transactions"`
+	SuccessfulTransactions int64        `json:"successful_transactions"`
+	FailedTransactions     int64        `json:"failed_transactions"`
+	ErroredTransactions    int64        `json:"errored_transactions"`
+	TimedOutTransactions   int64        `json:"timed_out_transactions"`
+	TotalBytesSent         int          `json:"total_bytes_sent"`
+	TotalBytesReceived     int          `json:"total_bytes_received"`
+	TransactionRate        float64      `json:"transaction_rate_per_second"`
+	ResponseTime           Distribution `json:"response_time"`
+}
+
+// ErrorRate returns the fraction of the transactions to the endpoint
+// which got no response.
+func (endpoint EndpointResult) ErrorRate() float64 {
+	return outcomeRate(endpoint.ErroredTransactions, endpoint.Transactions)
+}
+
+// FailureRate returns the fraction of the transactions to the endpoint
+// which got an unsuccessful response.
+func (endpoint EndpointResult) FailureRate() float64 {
+	return outcomeRate(endpoint.FailedTransactions, endpoint.Transactions)
+}
+
+// SchmokinResult holds the metrics of a run. Transactions which got no
+// response are errors while those which got an unsuccessful response are
+// failures, availability only counts the errors while the success rate
+// counts both.
 type SchmokinResult struct {
-	Transactions           int
-	Availability           float64
-	ElapsedTime            time.Duration
-	AverageResponseTime    float64
-	TotalBytesSent         int
-	TotalBytesReceived     int
-	TransactionRate        float64
-	ConcurrencyRate        float64
-	DataSendRate           float64
-	DataReceiveRate        float64
-	SuccessfulTransactions int64
-	FailedTransactions     int64
-	LongestTransaction     int64
-	ShortestTransaction    int64
+	Transactions           int              `json:"transactions"`
+	Availability           float64          `json:"availability"`
+	SuccessRate            float64          `json:"success_rate"`
+	FailureRate            float64          `json:"failure_rate"`
+	ErrorRate              float64          `json:"error_rate"`
+	ElapsedTime            time.Duration    `json:"elapsed_time_ns"`
+	StartTime              time.Time        `json:"start_time"`
+	EndTime                time.Time        `json:"end_time"`
+	AverageResponseTime    float64          `json:"average_response_time_ns"`
+	TotalBytesSent         int              `json:"total_bytes_sent"`
+	TotalBytesReceived     int              `json:"total_bytes_received"`
+	TransactionRate        float64          `json:"transaction_rate_per_second"`
+	ConcurrencyRate        float64          `json:"concurrency"`
+	DataSendRate           float64          `json:"data_send_rate_bytes_per_second"`
+	DataReceiveRate        float64          `json:"data_receive_rate_bytes_per_second"`
+	SuccessfulTransactions int64            `json:"successful_transactions"`
+	FailedTransactions     int64            `json:"failed_transactions"`
+	ErroredTransactions    int64            `json:"errored_transactions"`
+	TimedOutTransactions   int64            `json:"timed_out_transactions"`
+	DroppedIterations      int64            `json:"dropped_iterations"`
+	LateIterations         int64            `json:"late_iterations"`
+	LongestTransaction     int64            `json:"longest_transaction_ns"`
+	ShortestTransaction    int64            `json:"shortest_transaction_ns"`
+	ResponseTime           Distribution     `json:"response_time"`
+	CorrectedResponseTime  Distribution     `json:"corrected_response_time"`
+	DNSLookupTime          Distribution     `json:"dns_lookup_time"`
+	ConnectTime            Distribution     `json:"connect_time"`
+	TLSHandshakeTime       Distribution     `json:"tls_handshake_time"`
+	FirstByteTime          Distribution     `json:"first_byte_time"`
+	ContentTransferTime    Distribution     `json:"content_transfer_time"`
+	Stages                 []StageResult    `json:"stages"`
+	Endpoints              []EndpointResult `json:"endpoints"`
+	// Intervals holds the metrics of the transactions which finished
+	// during each interval of the run, as a time series.
+	Intervals []Interval `json:"intervals"`
+	// StatusCodes counts the transactions by the status code of their
+	// response and ErrorCategories counts the transactions which failed
+	// without a response, or while reading it, by the kind of error.
+	StatusCodes     map[int]int64    `json:"status_codes"`
+	ErrorCategories map[string]int64 `json:"error_categories"`
+	// Warmup holds the metrics of the transactions made during the
+	// warm-up, which are left out of the rest of the result.
+	Warmup StageResult `json:"warmup"`
+	// Interrupted is set when the run was interrupted before it finished
+	// so the result only covers the transactions made until then.
+	Interrupted bool `json:"interrupted"`
+}
+
+// summarise works out every distribution of the result from its histogram.
+func (result *SchmokinResult) summarise() {
+	for _, distribution := range []*Distribution{
+		&result.ResponseTime,
+		&result.CorrectedResponseTime,
+		&result.DNSLookupTime,
+		&result.ConnectTime,
+		&result.TLSHandshakeTime,
+		&result.FirstByteTime,
+		&result.ContentTransferTime,
+		&result.Warmup.ResponseTime,
+	} {
+		*distribution = summarised(*distribution)
+	}
+	for index := range result.Stages {
+		result.Stages[index].ResponseTime = summarised(result.Stages[index].ResponseTime)
+	}
+	for index := range result.Endpoints {
+		result.Endpoints[index].ResponseTime = summarised(result.Endpoints[index].ResponseTime)
+	}
+	for index := range result.Intervals {
+		result.Intervals[index].ResponseTime = summarised(result.Intervals[index].ResponseTime)
+	}
+}
+
+// SetOutcomeRates sets the availability and the rate of each outcome from
+// the number of transactions with each.
+func (result *SchmokinResult) SetOutcomeRates() {
+	result.SuccessRate = outcomeRate(result.SuccessfulTransactions, result.Transactions)
+	result.FailureRate = outcomeRate(result.FailedTransactions, result.Transactions)
+	result.ErrorRate = outcomeRate(result.ErroredTransactions, result.Transactions)
+	result.Availability = 1 - result.ErrorRate
 }
@@ -1,8 +1,14 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
 	"math/rand"
-	"strings"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,72 +21,305 @@ type SchmokinService struct {
 	random      bool
 	workerCount int
 	iterations  int
+	duration    time.Duration
+	stages      []Stage
+	rate        float64
+	maxWorkers  int
+	poisson     bool
+	headers     []string
+	timeout     time.Duration
+	tls         schmokinHTTP.TLSOptions
+	cookieJar   string
+	saveCookies bool
+	process     int
+	cookies     []byte
+	captureSize int64
 	httpClient  schmokinHTTP.Client
 	timer       utils.Timer
 	lock        sync.Mutex
 	waitGroup   sync.WaitGroup
+	users       int
+	interrupt   <-chan struct{}
+	startTime   time.Time
+	idleUsers   int
+	stage       int
+	stageStats  []*stats
+	endpoints   map[string]*stats
+	warmup      Warmup
+	warmupStats *stats
+	warmingUp   bool
+	interval    time.Duration
+	intervals   map[int64]*intervalStats
 	//TODO: Create a stats struct for these
 	transactions           int
 	errors                 int
+	failures               int
+	timeouts               int
 	totalBytesSent         int
 	totalBytesReceived     int
-	responseTime           metrics.Histogram
+	responseTime           *utils.Histogram
+	correctedResponseTime  *utils.Histogram
 	transactionRate        metrics.Meter
 	concurrencyCounter     metrics.Counter
 	concurrencyRate        metrics.Histogram
 	dataSendRate           metrics.Meter
 	dataReceiveRate        metrics.Meter
+	dnsLookupTime          *utils.Histogram
+	connectTime            *utils.Histogram
+	tlsHandshakeTime       *utils.Histogram
+	firstByteTime          *utils.Histogram
+	contentTransferTime    *utils.Histogram
 	successfulTransactions int
+	droppedIterations      int
+	lateIterations         int
+	warmupTransactions     int
+	warmupEnded            chan struct{}
+	warmupIntervals        map[int64]*intervalStats
+	statusCodes            map[int]int64
+	errorCategories        map[string]int64
 }
 
-func (schmokin *SchmokinService) worker(linesValue []string) {
-	for i := 0; i < len(linesValue) || (schmokin.iterations > 0 && i < schmokin.iterations); i++ {
-		line := linesValue[i%len(linesValue)]
-		var command = schmokinHTTP.Command{
-			Client: schmokin.httpClient,
-			Timer:  schmokin.timer,
+// newCookieJar creates the cookie jar of a virtual user, preloaded
+// with the cookies from the cookie file.
+func (schmokin *SchmokinService) newCookieJar() *schmokinHTTP.CookieJar {
+	jar := schmokinHTTP.NewCookieJar()
+	if schmokin.cookies != nil {
+		if err := jar.Load(bytes.NewReader(schmokin.cookies)); err != nil {
+			log.Println(err)
 		}
-		var args = strings.Fields(line)
-		schmokin.concurrencyCounter.Inc(1)
-		result := command.Execute(args)
-		schmokin.concurrencyCounter.Dec(1)
-		schmokin.concurrencyRate.Update(schmokin.concurrencyCounter.Count())
-		schmokin.lock.Lock()
-		if result.Error != nil {
-			schmokin.errors++
-		} else {
-			schmokin.successfulTransactions++
+	}
+	return jar
+}
+
+// saveCookieJar writes the cookies of a virtual user to a file named
+// after the cookie file, the process and the virtual user.
+func (schmokin *SchmokinService) saveCookieJar(user int, jar *schmokinHTTP.CookieJar) {
+	file, err := os.Create(fmt.Sprintf("%v.%d.%d", schmokin.cookieJar, schmokin.process, user))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer file.Close()
+	if err := jar.Save(file); err != nil {
+		log.Println(err)
+	}
+}
+
+// updatePhase records the duration of a phase which took place, phases
+// such as connecting do not happen when a connection is reused.
+func updatePhase(histogram *utils.Histogram, duration time.Duration) {
+	if duration > 0 {
+		histogram.Record(int64(duration))
+	}
+}
+
+// transactionsPerUser returns how many transactions each virtual user
+// makes, one iteration makes a single pass over the lines. Zero means the
+// virtual users loop over the lines until the duration or stages end.
+func (schmokin *SchmokinService) transactionsPerUser(lines int) int {
+	if (schmokin.duration > 0 || len(schmokin.stages) > 0) && schmokin.iterations == 0 {
+		return 0
+	}
+	if schmokin.iterations > 1 {
+		return schmokin.iterations
+	}
+	return lines
+}
+
+// transact makes one transaction for a virtual user and records its
+// result, returning false when the run ended while it was in flight.
+// Transactions scheduled for a time, rather than started as soon as the
+// last finished, also record how long they were delayed from that time.
+func (schmokin *SchmokinService) transact(ctx context.Context, jar *schmokinHTTP.CookieJar, line string, scheduled time.Time) bool {
+	var command = schmokinHTTP.Command{
+		Context:     ctx,
+		Client:      schmokin.httpClient,
+		Timer:       schmokin.timer,
+		Headers:     schmokin.headers,
+		Timeout:     schmokin.timeout,
+		TLS:         schmokin.tls,
+		Jar:         jar,
+		CaptureSize: schmokin.captureSize,
+		Scheduled:   scheduled,
+	}
+	stage, ok := schmokin.startTransaction()
+	if !ok {
+		return false
+	}
+	schmokin.concurrencyCounter.Inc(1)
+	result := command.ExecuteLine(line)
+	activeUsers := int(schmokin.concurrencyCounter.Count())
+	schmokin.concurrencyCounter.Dec(1)
+	if result.Error != nil && ctx.Err() != nil {
+		return false
+	}
+	schmokin.lock.Lock()
+	defer schmokin.lock.Unlock()
+	if schmokin.interval > 0 {
+		schmokin.recordInterval(result, activeUsers)
+	}
+	if schmokin.warmingUp {
+		schmokin.warmupStats.record(result)
+		return true
+	}
+	schmokin.concurrencyRate.Update(schmokin.concurrencyCounter.Count())
+	switch {
+	case result.IsError():
+		schmokin.errors++
+		if result.TimedOut {
+			schmokin.timeouts++
 		}
-		schmokin.transactions++
-		schmokin.totalBytesSent += result.TotalBytesSent
-		schmokin.totalBytesReceived += result.TotalBytesReceived
-		schmokin.responseTime.Update(int64(result.ResponseTime))
-		schmokin.dataSendRate.Mark(int64(result.TotalBytesSent))
-		schmokin.dataReceiveRate.Mark(int64(result.TotalBytesReceived))
-		schmokin.transactionRate.Mark(1)
-		schmokin.lock.Unlock()
-		if i > 0 && i == schmokin.iterations-1 {
+	case result.IsFailure():
+		schmokin.failures++
+	default:
+		schmokin.successfulTransactions++
+	}
+	schmokin.transactions++
+	if result.StatusCode > 0 {
+		schmokin.statusCodes[result.StatusCode]++
+	}
+	if result.ErrorCategory != "" {
+		schmokin.errorCategories[result.ErrorCategory]++
+	}
+	schmokin.totalBytesSent += result.TotalBytesSent
+	schmokin.totalBytesReceived += result.TotalBytesReceived
+	// Transactions which never got a response have no response time
+	// to record.
+	if result.ResponseTime > 0 {
+		schmokin.responseTime.Record(int64(result.ResponseTime))
+		schmokin.correctedResponseTime.Record(int64(result.CorrectedResponseTime))
+	}
+	updatePhase(schmokin.dnsLookupTime, result.DNSTime)
+	updatePhase(schmokin.connectTime, result.ConnectTime)
+	updatePhase(schmokin.tlsHandshakeTime, result.TLSTime)
+	updatePhase(schmokin.firstByteTime, result.FirstByteTime)
+	updatePhase(schmokin.contentTransferTime, result.ContentTransferTime)
+	schmokin.dataSendRate.Mark(int64(result.TotalBytesSent))
+	schmokin.dataReceiveRate.Mark(int64(result.TotalBytesReceived))
+	schmokin.transactionRate.Mark(1)
+	if stage >= 0 {
+		schmokin.stageStats[stage].record(result)
+	}
+	endpoint, ok := schmokin.endpoints[result.Name]
+	if !ok {
+		endpoint = newStats()
+		schmokin.endpoints[result.Name] = endpoint
+	}
+	endpoint.record(result)
+	return true
+}
+
+func (schmokin *SchmokinService) worker(ctx context.Context, user int, linesValue []string, transactions int, retire <-chan struct{}) {
+	jar := schmokin.newCookieJar()
+	for i := 0; (transactions == 0 || i < transactions) && !schmokin.stoppedUser(ctx, retire); i++ {
+		if !schmokin.transact(ctx, jar, linesValue[i%len(linesValue)], time.Time{}) {
+			// The run ended while the transaction was in flight
 			break
 		}
 	}
+	if schmokin.saveCookies {
+		schmokin.saveCookieJar(user, jar)
+	}
 	schmokin.waitGroup.Done()
 }
 
-func (schmokin *SchmokinService) Execute(lines []string) SchmokinResult {
-	timer := schmokin.timer.Start()
+// run starts the virtual users with the executor of the run, each making
+// up to the number of transactions or looping until the run ends when it is
+// zero. The arrival rate executor counts the transactions as though for a
+// single virtual user.
+func (schmokin *SchmokinService) run(ctx context.Context, lines []string, transactions int) {
+	if schmokin.rate > 0 {
+		schmokin.runArrivals(ctx, lines, transactions)
+	} else if len(schmokin.stages) > 0 {
+		schmokin.runStages(ctx, lines, transactions)
+	} else {
+		for i := 0; i < schmokin.workerCount; i++ {
+			schmokin.waitGroup.Add(1)
+			go schmokin.worker(ctx, i, lines, transactions, nil)
+		}
+	}
+}
+
+// Execute runs the virtual users over the lines. Cancelling the context
+// interrupts the run, no more transactions are started and those in flight
+// are left to finish before the partial result is returned.
+func (schmokin *SchmokinService) Execute(interrupt context.Context, lines []string) SchmokinResult {
+	schmokin.interrupt = interrupt.Done()
 	if schmokin.random {
 		//https://yourbasic.org/golang/shuffle-slice-array/
 		rand.Seed(time.Now().UnixNano())
 		rand.Shuffle(len(lines), func(i, j int) { lines[i], lines[j] = lines[j], lines[i] })
 	}
-	for i := 0; i < schmokin.workerCount; i++ {
-		schmokin.waitGroup.Add(1)
-		go schmokin.worker(lines)
+	if schmokin.cookieJar != "" {
+		cookies, err := ioutil.ReadFile(schmokin.cookieJar)
+		if err != nil {
+			log.Println(err)
+		}
+		schmokin.cookies = cookies
+	}
+	if schmokin.warmup.Enabled() {
+		schmokin.runWarmup(lines)
+	}
+	schmokin.lock.Lock()
+	schmokin.startTime = time.Now()
+	schmokin.lock.Unlock()
+	timer := schmokin.timer.Start()
+	ctx, cancel := context.WithCancel(context.Background())
+	if schmokin.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, schmokin.duration)
+	}
+	defer cancel()
+	schmokin.run(ctx, lines, schmokin.transactionsPerUser(len(lines)))
+	if len(schmokin.stages) > 0 && interrupt.Err() == nil {
+		// Transactions still in flight when the last stage ends are cancelled
+		cancel()
 	}
 	schmokin.waitGroup.Wait()
+	elapsed := timer.Stop()
+	schmokin.lock.Lock()
+	defer schmokin.lock.Unlock()
+	result := schmokin.counters(time.Now(), elapsed, time.Time{})
+	result.summarise()
+	result.Interrupted = interrupt.Err() != nil
+	return result
+}
+
+// Snapshot returns the result of the run so far as though it ended now,
+// with only the intervals starting from the given time so those already
+// sent are not built again. Before the run has started it returns an empty
+// result. Only the counts are copied while the transactions are held up,
+// the distributions are worked out after.
+func (schmokin *SchmokinService) Snapshot(intervalsFrom time.Time) SchmokinResult {
+	schmokin.lock.Lock()
+	if schmokin.startTime.IsZero() {
+		schmokin.lock.Unlock()
+		return SchmokinResult{}
+	}
+	now := time.Now()
+	result := schmokin.counters(now, now.Sub(schmokin.startTime), intervalsFrom)
+	schmokin.lock.Unlock()
+	result.summarise()
+	return result
+}
+
+// counters returns the result of the transactions made until the end time
+// with copies of the histograms in place of the distributions, which
+// summarise works out. The counts kept by status code and error are copied
+// as the run may still be adding to them.
+func (schmokin *SchmokinService) counters(endTime time.Time, elapsed time.Duration, intervalsFrom time.Time) SchmokinResult {
+	statusCodes := map[int]int64{}
+	for code, count := range schmokin.statusCodes {
+		statusCodes[code] = count
+	}
+	errorCategories := map[string]int64{}
+	for category, count := range schmokin.errorCategories {
+		errorCategories[category] = count
+	}
 	result := SchmokinResult{
 		Transactions:           schmokin.transactions,
-		ElapsedTime:            timer.Stop(),
+		ElapsedTime:            elapsed,
+		StartTime:              schmokin.startTime,
+		EndTime:                endTime,
 		TotalBytesSent:         schmokin.totalBytesSent,
 		TotalBytesReceived:     schmokin.totalBytesReceived,
 		AverageResponseTime:    schmokin.responseTime.Mean(),
@@ -89,23 +328,51 @@ func (schmokin *SchmokinService) Execute(lines []string) SchmokinResult {
 		DataSendRate:           schmokin.dataSendRate.RateMean(),
 		DataReceiveRate:        schmokin.dataReceiveRate.RateMean(),
 		SuccessfulTransactions: int64(schmokin.successfulTransactions),
-		FailedTransactions:     int64(schmokin.errors),
+		FailedTransactions:     int64(schmokin.failures),
+		ErroredTransactions:    int64(schmokin.errors),
+		TimedOutTransactions:   int64(schmokin.timeouts),
+		DroppedIterations:      int64(schmokin.droppedIterations),
+		LateIterations:         int64(schmokin.lateIterations),
 		LongestTransaction:     schmokin.responseTime.Max(),
 		ShortestTransaction:    schmokin.responseTime.Min(),
+		ResponseTime:           copyDistribution(schmokin.responseTime),
+		CorrectedResponseTime:  copyDistribution(schmokin.correctedResponseTime),
+		DNSLookupTime:          copyDistribution(schmokin.dnsLookupTime),
+		ConnectTime:            copyDistribution(schmokin.connectTime),
+		TLSHandshakeTime:       copyDistribution(schmokin.tlsHandshakeTime),
+		FirstByteTime:          copyDistribution(schmokin.firstByteTime),
+		ContentTransferTime:    copyDistribution(schmokin.contentTransferTime),
+		Stages:                 schmokin.stageResults(),
+		Endpoints:              schmokin.endpointResults(endTime.Sub(schmokin.startTime)),
+		Intervals:              schmokin.intervalResults(intervalsFrom),
+		StatusCodes:            statusCodes,
+		ErrorCategories:        errorCategories,
 	}
-	if schmokin.errors == 0 {
-		result.Availability = 1
-	} else {
-		availability := float64(schmokin.errors) / float64(schmokin.transactions)
-		if availability < 1 {
-			result.Availability = float64(1) - availability
-		} else {
-			if schmokin.errors == schmokin.transactions {
-				result.Availability = 0
-			} else {
-				result.Availability = availability
-			}
-		}
+	if schmokin.warmup.Enabled() {
+		result.Warmup = schmokin.warmupStats.result(schmokin.warmup.Duration, 0)
 	}
+	result.SetOutcomeRates()
 	return result
 }
+
+// endpointResults returns the results of each endpoint ordered by name.
+func (schmokin *SchmokinService) endpointResults(elapsed time.Duration) []EndpointResult {
+	names := []string{}
+	for name := range schmokin.endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := []EndpointResult{}
+	for _, name := range names {
+		results = append(results, schmokin.endpoints[name].endpointResult(name, elapsed))
+	}
+	return results
+}
+
+func (schmokin *SchmokinService) stageResults() []StageResult {
+	results := []StageResult{}
+	for index, stage := range schmokin.stages {
+		results = append(results, schmokin.stageStats[index].result(stage.Duration, stage.Target))
+	}
+	return results
+}
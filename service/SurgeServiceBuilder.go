@@ -2,6 +2,7 @@ package service
 
 import (
 	"sync"
+	"time"
 
 	"github.com/rcrowley/go-metrics"
 	schmokinHTTP "github.com/reaandrew/schmokin/infrastructure/http"
@@ -13,8 +14,6 @@ type SchmokinServiceBuilder struct {
 }
 
 func NewSchmokinServiceBuilder() *SchmokinServiceBuilder {
-	s := metrics.NewExpDecaySample(1028, 0.015) // or metrics.NewUniformSample(1028)
-	h := metrics.NewHistogram(s)
 	m := metrics.NewMeter()
 	sc := metrics.NewExpDecaySample(1028, 0.015) // or metrics.NewUniformSample(1028)
 	c := metrics.NewHistogram(sc)
@@ -24,18 +23,31 @@ func NewSchmokinServiceBuilder() *SchmokinServiceBuilder {
 
 	return &SchmokinServiceBuilder{
 		service: &SchmokinService{
-			workerCount:        1,
-			iterations:         1,
-			httpClient:         schmokinHTTP.NewDefaultClient(),
-			timer:              &utils.DefaultTimer{},
-			lock:               sync.Mutex{},
-			waitGroup:          sync.WaitGroup{},
-			responseTime:       h,
-			transactionRate:    m,
-			concurrencyCounter: co,
-			concurrencyRate:    c,
-			dataSendRate:       sendRate,
-			dataReceiveRate:    receiveRate,
+			workerCount:           1,
+			stage:                 -1,
+			interval:              DefaultInterval,
+			intervals:             map[int64]*intervalStats{},
+			warmupIntervals:       map[int64]*intervalStats{},
+			warmupStats:           newStats(),
+			endpoints:             map[string]*stats{},
+			statusCodes:           map[int]int64{},
+			errorCategories:       map[string]int64{},
+			httpClient:            schmokinHTTP.NewDefaultClient(),
+			timer:                 &utils.DefaultTimer{},
+			lock:                  sync.Mutex{},
+			waitGroup:             sync.WaitGroup{},
+			responseTime:          utils.NewHistogram(),
+			correctedResponseTime: utils.NewHistogram(),
+			transactionRate:       m,
+			concurrencyCounter:    co,
+			concurrencyRate:       c,
+			dataSendRate:          sendRate,
+			dataReceiveRate:       receiveRate,
+			dnsLookupTime:         utils.NewHistogram(),
+			connectTime:           utils.NewHistogram(),
+			tlsHandshakeTime:      utils.NewHistogram(),
+			firstByteTime:         utils.NewHistogram(),
+			contentTransferTime:   utils.NewHistogram(),
 		},
 	}
 }
@@ -50,11 +62,101 @@ func (builder *SchmokinServiceBuilder) SetIterations(count int) *SchmokinService
 	return builder
 }
 
+// SetDuration makes the virtual users loop over the lines until the
+// duration has passed. When an iteration count is also set each virtual
+// user stops at whichever comes first.
+func (builder *SchmokinServiceBuilder) SetDuration(duration time.Duration) *SchmokinServiceBuilder {
+	builder.service.duration = duration
+	return builder
+}
+
+// SetStages replaces the fixed number of workers with virtual users which
+// are added and retired to follow the stages.
+func (builder *SchmokinServiceBuilder) SetStages(stages []Stage) *SchmokinServiceBuilder {
+	builder.service.stages = stages
+	builder.service.stageStats = make([]*stats, len(stages))
+	for index := range stages {
+		builder.service.stageStats[index] = newStats()
+	}
+	return builder
+}
+
+// SetRate starts iterations at the rate, in iterations per second, instead
+// of each virtual user starting its next iteration when the last finishes.
+// With stages the rate ramps from this rate to the target of each stage.
+func (builder *SchmokinServiceBuilder) SetRate(rate float64) *SchmokinServiceBuilder {
+	builder.service.rate = rate
+	return builder
+}
+
+// SetMaxWorkers limits how many virtual users can be started to keep up
+// with the arrival rate, it is never less than the number of workers.
+func (builder *SchmokinServiceBuilder) SetMaxWorkers(count int) *SchmokinServiceBuilder {
+	builder.service.maxWorkers = count
+	return builder
+}
+
+// SetPoisson makes the time between iterations started at the arrival
+// rate exponentially distributed, as in a Poisson process.
+func (builder *SchmokinServiceBuilder) SetPoisson(value bool) *SchmokinServiceBuilder {
+	builder.service.poisson = value
+	return builder
+}
+
+// SetWarmup makes the virtual users warm up before the run, the
+// transactions made during the warm-up are reported apart from the rest.
+func (builder *SchmokinServiceBuilder) SetWarmup(warmup Warmup) *SchmokinServiceBuilder {
+	builder.service.warmup = warmup
+	return builder
+}
+
+// SetInterval sets how long each interval of the time series lasts, zero
+// records no time series.
+func (builder *SchmokinServiceBuilder) SetInterval(interval time.Duration) *SchmokinServiceBuilder {
+	builder.service.interval = interval
+	return builder
+}
+
 func (builder *SchmokinServiceBuilder) SetRandom(value bool) *SchmokinServiceBuilder {
 	builder.service.random = value
 	return builder
 }
 
+func (builder *SchmokinServiceBuilder) SetHeaders(headers []string) *SchmokinServiceBuilder {
+	builder.service.headers = headers
+	return builder
+}
+
+func (builder *SchmokinServiceBuilder) SetTimeout(timeout time.Duration) *SchmokinServiceBuilder {
+	builder.service.timeout = timeout
+	return builder
+}
+
+func (builder *SchmokinServiceBuilder) SetTLSOptions(options schmokinHTTP.TLSOptions) *SchmokinServiceBuilder {
+	builder.service.tls = options
+	return builder
+}
+
+func (builder *SchmokinServiceBuilder) SetCookieJar(path string) *SchmokinServiceBuilder {
+	builder.service.cookieJar = path
+	return builder
+}
+
+func (builder *SchmokinServiceBuilder) SetSaveCookies(value bool) *SchmokinServiceBuilder {
+	builder.service.saveCookies = value
+	return builder
+}
+
+func (builder *SchmokinServiceBuilder) SetProcess(index int) *SchmokinServiceBuilder {
+	builder.service.process = index
+	return builder
+}
+
+func (builder *SchmokinServiceBuilder) SetCaptureSize(size int64) *SchmokinServiceBuilder {
+	builder.service.captureSize = size
+	return builder
+}
+
 func (builder *SchmokinServiceBuilder) SetClient(client schmokinHTTP.Client) *SchmokinServiceBuilder {
 	builder.service.httpClient = client
 	return builder
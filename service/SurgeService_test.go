@@ -1,8 +1,14 @@
 package service_test
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -22,6 +28,14 @@ type SchmokinServiceTransactionTestCase struct {
 // All these tests need to be on the SchmokinService not the SchmokinService
 // The Schmokin CLI should be tested to ensure it invokes the correct proxy.
 
+// closedServerURL returns the URL of a server which has already been
+// closed so connections to it are refused.
+func closedServerURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
 func Test_SchmokinServiceReturnNumberOfTransactions(t *testing.T) {
 	cases := []SchmokinServiceTransactionTestCase{
 		{Urls: 1, Workers: 1, Iterations: 1, ExpectedTransactions: 1},
@@ -48,30 +62,30 @@ func Test_SchmokinServiceReturnNumberOfTransactions(t *testing.T) {
 				SetWorkers(testCase.Workers).
 				SetIterations(testCase.Iterations).
 				Build()
-			result := schmokinService.Execute(lines)
+			result := schmokinService.Execute(context.Background(), lines)
 
 			assert.Equal(t, testCase.ExpectedTransactions, result.Transactions)
 		})
 	}
 }
 
-type SchmokinServiceAvailabilityTestCase struct {
-	StatusCodes          []int
-	ExpectedAvailability float64
+type SchmokinServiceSuccessRateTestCase struct {
+	StatusCodes         []int
+	ExpectedSuccessRate float64
 }
 
-func Test_SchmokinServiceReturnsAvailability(t *testing.T) {
-	cases := []SchmokinServiceAvailabilityTestCase{
-		{StatusCodes: []int{200, 200, 500, 500}, ExpectedAvailability: float64(0.5)},
-		{StatusCodes: []int{200, 200}, ExpectedAvailability: float64(1)},
-		{StatusCodes: []int{200, 201, 202}, ExpectedAvailability: float64(1)},
-		{StatusCodes: []int{200, 200, 404, 500}, ExpectedAvailability: float64(0.5)},
-		{StatusCodes: []int{500, 500, 500, 500}, ExpectedAvailability: float64(0)},
+func Test_SchmokinServiceReturnsSuccessRate(t *testing.T) {
+	cases := []SchmokinServiceSuccessRateTestCase{
+		{StatusCodes: []int{200, 200, 500, 500}, ExpectedSuccessRate: float64(0.5)},
+		{StatusCodes: []int{200, 200}, ExpectedSuccessRate: float64(1)},
+		{StatusCodes: []int{200, 201, 202}, ExpectedSuccessRate: float64(1)},
+		{StatusCodes: []int{200, 200, 404, 500}, ExpectedSuccessRate: float64(0.5)},
+		{StatusCodes: []int{500, 500, 500, 500}, ExpectedSuccessRate: float64(0)},
 	}
 
 	for _, currentTestCase := range cases {
 		testCase := currentTestCase
-		t.Run(fmt.Sprintf("Test_SchmokinServiceReturnAvailabilityOf%v%%", testCase.ExpectedAvailability*100), func(t *testing.T) {
+		t.Run(fmt.Sprintf("Test_SchmokinServiceReturnSuccessRateOf%v%%", testCase.ExpectedSuccessRate*100), func(t *testing.T) {
 			lines := utils.CreateRandomLines(len(testCase.StatusCodes))
 			httpClient := schmokinHTTP.NewFakeClient()
 			schmokinService := service.NewSchmokinServiceBuilder().
@@ -82,13 +96,37 @@ func Test_SchmokinServiceReturnsAvailability(t *testing.T) {
 				response.StatusCode = testCase.StatusCodes[count]
 				count++
 			}
-			result := schmokinService.Execute(lines)
+			result := schmokinService.Execute(context.Background(), lines)
 
-			assert.Equal(t, testCase.ExpectedAvailability, result.Availability)
+			assert.Equal(t, testCase.ExpectedSuccessRate, result.SuccessRate)
+			assert.Equal(t, 1-testCase.ExpectedSuccessRate, result.FailureRate)
+			// Responses from the server count against the success rate only
+			assert.Equal(t, float64(1), result.Availability)
 		})
 	}
 }
 
+func Test_SchmokinServiceReturnsAvailability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetIterations(4).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{
+		server.URL + "/1 -X GET",
+		closedServerURL() + " -X GET",
+	})
+
+	assert.Equal(t, int64(2), result.FailedTransactions)
+	assert.Equal(t, int64(2), result.ErroredTransactions)
+	assert.Equal(t, float64(0.5), result.Availability)
+	assert.Equal(t, float64(0.5), result.ErrorRate)
+	assert.Equal(t, float64(0.5), result.FailureRate)
+	assert.Equal(t, float64(0), result.SuccessRate)
+}
+
 func Test_SchmokinServiceReturnsElapsedTime(t *testing.T) {
 	lines := utils.CreateRandomLines(1)
 	expectedElapsed := 100 * time.Second
@@ -99,46 +137,358 @@ func Test_SchmokinServiceReturnsElapsedTime(t *testing.T) {
 		SetTimer(timer).
 		SetClient(httpClient).
 		Build()
-	result := schmokinService.Execute(lines)
+	result := schmokinService.Execute(context.Background(), lines)
 
 	assert.Equal(t, expectedElapsed, result.ElapsedTime)
 }
 
-func Test_SchmokinServiceReturnsTotalBytesSent(t *testing.T) {
+func Test_SchmokinServiceLoopsUntilTheDurationEnds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+	duration := 300 * time.Millisecond
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(2).
+		SetDuration(duration).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.True(t, result.Transactions > 4, "transactions %v", result.Transactions)
+	assert.Equal(t, int64(0), result.FailedTransactions)
+	assert.True(t, result.ElapsedTime >= duration, "elapsed %v", result.ElapsedTime)
+	assert.True(t, result.ElapsedTime < duration+250*time.Millisecond, "elapsed %v", result.ElapsedTime)
+}
+
+func Test_SchmokinServiceStopsAtTheIterationsBeforeTheDuration(t *testing.T) {
 	lines := utils.CreateRandomLines(1)
 	httpClient := schmokinHTTP.NewFakeClient()
 	schmokinService := service.NewSchmokinServiceBuilder().
 		SetClient(httpClient).
+		SetWorkers(2).
+		SetIterations(3).
+		SetDuration(time.Minute).
 		Build()
-	result := schmokinService.Execute(lines)
+	result := schmokinService.Execute(context.Background(), lines)
 
-	// This is the size of one request dumped
-	assert.Equal(t, result.TotalBytesSent, 96)
+	assert.Equal(t, 6, result.Transactions)
+}
+
+func Test_SchmokinServiceCancelsTransactionsInFlightWhenTheDurationEnds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetDuration(200 * time.Millisecond).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Equal(t, 0, result.Transactions)
+	assert.Equal(t, int64(0), result.FailedTransactions)
+	assert.True(t, result.ElapsedTime < time.Second, "elapsed %v", result.ElapsedTime)
+}
+
+func Test_SchmokinServiceFinishesTransactionsInFlightWhenInterrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(2).
+		SetDuration(10 * time.Second).
+		Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(300*time.Millisecond, cancel)
+	result := schmokinService.Execute(ctx, []string{server.URL + "/1 -X GET"})
+
+	// Each virtual user finishes its second transaction after the interrupt
+	assert.True(t, result.Interrupted)
+	assert.Equal(t, 4, result.Transactions)
+	assert.Equal(t, int64(0), result.FailedTransactions)
+	assert.True(t, result.ElapsedTime < time.Second, "elapsed %v", result.ElapsedTime)
+}
+
+// countingListener counts the bytes the server reads and writes
+// so they can be compared with the bytes counted by the client.
+type countingListener struct {
+	net.Listener
+	read    int64
+	written int64
+}
+
+type countingServerConn struct {
+	net.Conn
+	listener *countingListener
+}
+
+func (listener *countingListener) Accept() (net.Conn, error) {
+	conn, err := listener.Listener.Accept()
+	return &countingServerConn{Conn: conn, listener: listener}, err
+}
+
+func (conn *countingServerConn) Read(b []byte) (int, error) {
+	n, err := conn.Conn.Read(b)
+	atomic.AddInt64(&conn.listener.read, int64(n))
+	return n, err
+}
+
+func (conn *countingServerConn) Write(b []byte) (int, error) {
+	n, err := conn.Conn.Write(b)
+	atomic.AddInt64(&conn.listener.written, int64(n))
+	return n, err
+}
+
+func executeAgainstCountingServer() (*countingListener, service.SchmokinResult) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flushing twice sends a chunked body
+		io.WriteString(w, "hello ")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "world")
+	}))
+	listener := &countingListener{Listener: server.Listener}
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetClient(schmokinHTTP.NewDefaultClient()).
+		SetIterations(3).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -d hello"})
+	return listener, result
+}
+
+func Test_SchmokinServiceReturnsTotalBytesSent(t *testing.T) {
+	listener, result := executeAgainstCountingServer()
+
+	assert.Equal(t, int(atomic.LoadInt64(&listener.read)), result.TotalBytesSent)
 }
 
 func Test_SchmokinServiceReturnsTotalBytesReceived(t *testing.T) {
+	listener, result := executeAgainstCountingServer()
+
+	assert.Equal(t, int(atomic.LoadInt64(&listener.written)), result.TotalBytesReceived)
+}
+
+func Test_SchmokinServiceReturnsAverageResponseTime(t *testing.T) {
 	lines := utils.CreateRandomLines(1)
+	expectedDuration := 1 * time.Minute
+	timer := utils.NewFakeTimer(expectedDuration)
 	httpClient := schmokinHTTP.NewFakeClient()
 	schmokinService := service.NewSchmokinServiceBuilder().
+		SetTimer(timer).
 		SetClient(httpClient).
 		Build()
-	result := schmokinService.Execute(lines)
+	result := schmokinService.Execute(context.Background(), lines)
 
 	// This is the size of one request dumped
-	assert.Equal(t, result.TotalBytesReceived, 38)
+	assert.Equal(t, float64(expectedDuration), result.AverageResponseTime)
 }
 
-func Test_SchmokinServiceReturnsAverageResponseTime(t *testing.T) {
+func Test_SchmokinServiceReturnsResponseTimePercentiles(t *testing.T) {
 	lines := utils.CreateRandomLines(1)
-	expectedDuration := 1 * time.Minute
+	expectedDuration := 250 * time.Millisecond
 	timer := utils.NewFakeTimer(expectedDuration)
 	httpClient := schmokinHTTP.NewFakeClient()
 	schmokinService := service.NewSchmokinServiceBuilder().
 		SetTimer(timer).
 		SetClient(httpClient).
+		SetWorkers(2).
+		SetIterations(5).
 		Build()
-	result := schmokinService.Execute(lines)
+	result := schmokinService.Execute(context.Background(), lines)
 
-	// This is the size of one request dumped
-	assert.Equal(t, float64(expectedDuration), result.AverageResponseTime)
+	distribution := result.ResponseTime
+	assert.Equal(t, int64(10), distribution.Count)
+	assert.Equal(t, float64(0), distribution.StdDev)
+	for _, percentile := range []int64{distribution.P50, distribution.P75, distribution.P90, distribution.P95, distribution.P99, distribution.P999, distribution.Max} {
+		assert.Equal(t, int64(expectedDuration), percentile)
+	}
+}
+
+func Test_SchmokinServiceBreaksTheResultDownByEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	// Each iteration makes one transaction so two pass over the lines
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetIterations(8).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{
+		server.URL + "/slow -X GET",
+		server.URL + "/fast?page=1 -X GET",
+		server.URL + "/fast?page=2 -X GET",
+		server.URL + "/missing -X DELETE --name remove",
+	})
+
+	assert.Len(t, result.Endpoints, 3)
+	fast, slow, remove := result.Endpoints[0], result.Endpoints[1], result.Endpoints[2]
+	assert.Equal(t, "GET /fast", fast.Name)
+	assert.Equal(t, 4, fast.Transactions)
+	assert.Equal(t, float64(0), fast.ErrorRate())
+	assert.True(t, fast.ResponseTime.P99 < int64(50*time.Millisecond), "p99 %v", time.Duration(fast.ResponseTime.P99))
+	assert.Equal(t, "GET /slow", slow.Name)
+	assert.Equal(t, 2, slow.Transactions)
+	assert.True(t, slow.ResponseTime.P50 >= int64(50*time.Millisecond), "p50 %v", time.Duration(slow.ResponseTime.P50))
+	assert.Equal(t, "remove", remove.Name)
+	assert.Equal(t, float64(1), remove.FailureRate())
+	assert.Equal(t, float64(0), remove.ErrorRate())
+	assert.True(t, slow.TransactionRate > 0)
+}
+
+func Test_SchmokinServiceCountsStatusCodesAndErrorCategories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetIterations(6).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{
+		server.URL + "/ok -X GET",
+		server.URL + "/unavailable -X GET",
+		closedServerURL() + " -X GET",
+	})
+
+	assert.Equal(t, map[int]int64{200: 2, 503: 2}, result.StatusCodes)
+	assert.Equal(t, map[string]int64{schmokinHTTP.ErrorConnectionRefused: 2}, result.ErrorCategories)
+	assert.Equal(t, int64(2), result.FailedTransactions)
+	assert.Equal(t, int64(2), result.ErroredTransactions)
+}
+
+func Test_SchmokinServiceRecordsATimeSeriesOfIntervals(t *testing.T) {
+	server := createDelayedServer(10 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(2).
+		SetDuration(350 * time.Millisecond).
+		SetInterval(100 * time.Millisecond).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.True(t, len(result.Intervals) >= 4, "intervals %v", len(result.Intervals))
+	transactions := 0
+	for index, interval := range result.Intervals {
+		assert.Equal(t, 100*time.Millisecond, interval.Duration)
+		assert.Equal(t, interval.StartTime, interval.StartTime.Truncate(100*time.Millisecond))
+		if index > 0 {
+			assert.True(t, interval.StartTime.After(result.Intervals[index-1].StartTime))
+		}
+		assert.True(t, interval.ActiveUsers >= 1 && interval.ActiveUsers <= 2, "active users %v", interval.ActiveUsers)
+		assert.Equal(t, int64(interval.Transactions), interval.ResponseTime.Count)
+		assert.True(t, interval.ResponseTime.P50 >= int64(10*time.Millisecond), "p50 %v", time.Duration(interval.ResponseTime.P50))
+		transactions += interval.Transactions
+	}
+	assert.Equal(t, result.Transactions, transactions)
+}
+
+func Test_SchmokinServiceSnapshotsTheIntervalsFromATime(t *testing.T) {
+	server := createDelayedServer(10 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(2).
+		SetDuration(350 * time.Millisecond).
+		SetInterval(100 * time.Millisecond).
+		Build()
+	assert.True(t, schmokinService.Snapshot(time.Time{}).StartTime.IsZero())
+	done := make(chan service.SchmokinResult)
+	go func() {
+		done <- schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+	}()
+
+	time.Sleep(250 * time.Millisecond)
+	snapshot := schmokinService.Snapshot(time.Time{})
+	assert.True(t, len(snapshot.Intervals) >= 2, "intervals %v", len(snapshot.Intervals))
+	assert.True(t, snapshot.ResponseTime.P50 >= int64(10*time.Millisecond), "p50 %v", time.Duration(snapshot.ResponseTime.P50))
+	last := snapshot.Intervals[len(snapshot.Intervals)-1]
+	later := schmokinService.Snapshot(last.StartTime)
+	assert.True(t, later.Transactions >= snapshot.Transactions)
+	assert.Equal(t, last.StartTime, later.Intervals[0].StartTime)
+	for _, interval := range later.Intervals {
+		assert.False(t, interval.StartTime.Before(last.StartTime))
+		assert.Equal(t, int64(interval.Transactions), interval.ResponseTime.Count)
+	}
+	<-done
+}
+
+func Test_SchmokinServiceRecordsNoTimeSeriesWithoutAnInterval(t *testing.T) {
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetClient(schmokinHTTP.NewFakeClient()).
+		SetInterval(0).
+		Build()
+	result := schmokinService.Execute(context.Background(), utils.CreateRandomLines(2))
+
+	assert.Equal(t, 2, result.Transactions)
+	assert.Empty(t, result.Intervals)
+}
+
+func Test_SchmokinServiceReportsItsProgress(t *testing.T) {
+	server := createDelayedServer(20 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(3).
+		SetDuration(300 * time.Millisecond).
+		Build()
+	done := make(chan service.SchmokinResult)
+	go func() {
+		done <- schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+	}()
+
+	time.Sleep(150 * time.Millisecond)
+	progress := schmokinService.Progress()
+	assert.True(t, progress.ActiveUsers >= 1 && progress.ActiveUsers <= 3, "active users %v", progress.ActiveUsers)
+	assert.True(t, progress.Transactions > 0)
+	assert.Equal(t, int64(progress.Transactions), progress.ResponseTime.Count)
+	assert.True(t, progress.ResponseTime.P95 >= int64(20*time.Millisecond), "p95 %v", time.Duration(progress.ResponseTime.P95))
+
+	result := <-done
+	final := schmokinService.Progress()
+	assert.Equal(t, 0, final.ActiveUsers)
+	assert.Equal(t, result.Transactions, final.Transactions)
+	// Taking the progress leaves it for the next caller
+	assert.Equal(t, int64(result.Transactions), final.ResponseTime.Count)
+	assert.Equal(t, final, schmokinService.Progress())
+	recent := final.ResponseTime.Histogram.Since(progress.ResponseTime.Histogram)
+	assert.Equal(t, int64(result.Transactions-progress.Transactions), recent.Count())
+}
+
+func Test_SchmokinServiceGivesEachVirtualUserACookieJar(t *testing.T) {
+	lines := utils.CreateRandomLines(1)
+	httpClient := schmokinHTTP.NewFakeClient()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetClient(httpClient).
+		SetWorkers(2).
+		SetIterations(2).
+		Build()
+	var lock sync.Mutex
+	users := 0
+	httpClient.Interceptor = func(response *http.Response) {
+		lock.Lock()
+		defer lock.Unlock()
+		if _, err := response.Request.Cookie("user"); err != nil {
+			users++
+			response.Header.Add("Set-Cookie", fmt.Sprintf("user=%v", users))
+		}
+	}
+	schmokinService.Execute(context.Background(), lines)
+
+	cookies := map[string]int{}
+	for _, request := range httpClient.Requests {
+		if cookie, err := request.Cookie("user"); err == nil {
+			cookies[cookie.Value]++
+		}
+	}
+	assert.Equal(t, map[string]int{"1": 1, "2": 1}, cookies)
 }
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// thresholdPattern matches an expression such as p95<300ms, optionally
+// prefixed by the name of an endpoint and a colon e.g. GET /users:p95<300ms.
+var thresholdPattern = regexp.MustCompile(`^\s*(?:(.+):)?\s*([a-z0-9_.]+)\s*(<=|>=|==|<|>)\s*(\S+)\s*$`)
+
+// The units the metrics of thresholds are measured in.
+const (
+	unitMilliseconds = "ms"
+	unitPercent      = "%"
+	unitPerSecond    = "/s"
+	unitCount        = ""
+)
+
+// thresholdMetrics holds what the metrics of thresholds are worked out
+// from, for the whole run or one endpoint.
+type thresholdMetrics struct {
+	transactions    int
+	successful      int64
+	failed          int64
+	errored         int64
+	timedOut        int64
+	transactionRate float64
+	responseTime    Distribution
+}
+
+func milliseconds(value float64) float64 {
+	return value / float64(time.Millisecond)
+}
+
+// thresholdMetric works out the value of a metric, in its unit.
+type thresholdMetric struct {
+	unit  string
+	value func(metrics thresholdMetrics) float64
+}
+
+var thresholdMetricNames = map[string]thresholdMetric{
+	"p50":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.P50)) }},
+	"p75":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.P75)) }},
+	"p90":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.P90)) }},
+	"p95":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.P95)) }},
+	"p99":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.P99)) }},
+	"p99.9":        {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.P999)) }},
+	"avg":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(m.responseTime.Mean) }},
+	"min":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.Min)) }},
+	"max":          {unitMilliseconds, func(m thresholdMetrics) float64 { return milliseconds(float64(m.responseTime.Max)) }},
+	"availability": {unitPercent, func(m thresholdMetrics) float64 { return (1 - outcomeRate(m.errored, m.transactions)) * 100 }},
+	"success_rate": {unitPercent, func(m thresholdMetrics) float64 { return outcomeRate(m.successful, m.transactions) * 100 }},
+	"failure_rate": {unitPercent, func(m thresholdMetrics) float64 { return outcomeRate(m.failed, m.transactions) * 100 }},
+	"error_rate":   {unitPercent, func(m thresholdMetrics) float64 { return outcomeRate(m.errored, m.transactions) * 100 }},
+	"rps":          {unitPerSecond, func(m thresholdMetrics) float64 { return m.transactionRate }},
+	"transactions": {unitCount, func(m thresholdMetrics) float64 { return float64(m.transactions) }},
+	"failed":       {unitCount, func(m thresholdMetrics) float64 { return float64(m.failed) }},
+	"errored":      {unitCount, func(m thresholdMetrics) float64 { return float64(m.errored) }},
+	"timed_out":    {unitCount, func(m thresholdMetrics) float64 { return float64(m.timedOut) }},
+}
+
+// Threshold is a condition the result of a run has to meet for it to pass,
+// such as p95<300ms, for the whole run or for one endpoint.
+type Threshold struct {
+	Expression string  `json:"expression"`
+	Endpoint   string  `json:"endpoint"`
+	Metric     string  `json:"metric"`
+	Operator   string  `json:"operator"`
+	Value      float64 `json:"value"`
+}
+
+// ParseThreshold parses a threshold expression. Response times are given
+// as a duration e.g. 300ms or as a number of milliseconds and rates as
+// percentages.
+func ParseThreshold(expression string) (Threshold, error) {
+	match := thresholdPattern.FindStringSubmatch(expression)
+	if match == nil {
+		return Threshold{}, fmt.Errorf("the threshold %q must be a metric, an operator and a value e.g. p95<300ms", expression)
+	}
+	threshold := Threshold{
+		Expression: strings.TrimSpace(expression),
+		Endpoint:   strings.TrimSpace(match[1]),
+		Metric:     match[2],
+		Operator:   match[3],
+	}
+	metric, ok := thresholdMetricNames[threshold.Metric]
+	if !ok {
+		return Threshold{}, fmt.Errorf("the threshold %q has an unknown metric %q", expression, threshold.Metric)
+	}
+	value := match[4]
+	if metric.unit == unitMilliseconds {
+		if duration, err := time.ParseDuration(value); err == nil {
+			threshold.Value = milliseconds(float64(duration))
+			return threshold, nil
+		}
+	}
+	if metric.unit == unitPercent {
+		value = strings.TrimSuffix(value, "%")
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Threshold{}, fmt.Errorf("the threshold %q has an invalid value %q", expression, match[4])
+	}
+	threshold.Value = parsed
+	return threshold, nil
+}
+
+// ParseThresholds parses every threshold expression.
+func ParseThresholds(expressions []string) ([]Threshold, error) {
+	thresholds := []Threshold{}
+	for _, expression := range expressions {
+		threshold, err := ParseThreshold(expression)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds, nil
+}
+
+// Unit returns the unit the metric of the threshold is measured in.
+func (threshold Threshold) Unit() string {
+	return thresholdMetricNames[threshold.Metric].unit
+}
+
+// Irrecoverable reports whether the threshold can never pass again once it
+// has failed during a run, as its metric only moves one way, such as the
+// longest response time or a count of failures staying below a limit.
+func (threshold Threshold) Irrecoverable() bool {
+	switch threshold.Metric {
+	case "max", "failed", "errored", "timed_out":
+		return threshold.Operator == "<" || threshold.Operator == "<="
+	case "min":
+		return threshold.Operator == ">" || threshold.Operator == ">="
+	default:
+		return false
+	}
+}
+
+func (threshold Threshold) compare(value float64) bool {
+	switch threshold.Operator {
+	case "<":
+		return value < threshold.Value
+	case "<=":
+		return value <= threshold.Value
+	case ">":
+		return value > threshold.Value
+	case ">=":
+		return value >= threshold.Value
+	default:
+		return value == threshold.Value
+	}
+}
+
+// ThresholdResult holds whether a threshold passed and the value of its
+// metric.
+type ThresholdResult struct {
+	Threshold
+	Actual float64 `json:"actual"`
+	Passed bool    `json:"passed"`
+	// Missing is set when the result has no transactions to the endpoint
+	// of the threshold, which then fails.
+	Missing bool `json:"missing"`
+}
+
+// Evaluate checks the threshold against the result of a run.
+func (threshold Threshold) Evaluate(result SchmokinResult) ThresholdResult {
+	metrics := thresholdMetrics{
+		transactions:    result.Transactions,
+		successful:      result.SuccessfulTransactions,
+		failed:          result.FailedTransactions,
+		errored:         result.ErroredTransactions,
+		timedOut:        result.TimedOutTransactions,
+		transactionRate: result.TransactionRate,
+		responseTime:    result.ResponseTime,
+	}
+	if threshold.Endpoint != "" {
+		found := false
+		for _, endpoint := range result.Endpoints {
+			if endpoint.Name == threshold.Endpoint {
+				found = true
+				metrics = thresholdMetrics{
+					transactions:    endpoint.Transactions,
+					successful:      endpoint.SuccessfulTransactions,
+					failed:          endpoint.FailedTransactions,
+					errored:         endpoint.ErroredTransactions,
+					timedOut:        endpoint.TimedOutTransactions,
+					transactionRate: endpoint.TransactionRate,
+					responseTime:    endpoint.ResponseTime,
+				}
+			}
+		}
+		if !found {
+			return ThresholdResult{Threshold: threshold, Missing: true}
+		}
+	}
+	actual := thresholdMetricNames[threshold.Metric].value(metrics)
+	return ThresholdResult{
+		Threshold: threshold,
+		Actual:    actual,
+		Passed:    threshold.compare(actual),
+	}
+}
+
+// EvaluateThresholds checks every threshold against the result of a run.
+func EvaluateThresholds(thresholds []Threshold, result SchmokinResult) []ThresholdResult {
+	results := []ThresholdResult{}
+	for _, threshold := range thresholds {
+		results = append(results, threshold.Evaluate(result))
+	}
+	return results
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseThreshold(t *testing.T) {
+	cases := map[string]service.Threshold{
+		"p95<300ms":            {Expression: "p95<300ms", Metric: "p95", Operator: "<", Value: 300},
+		"p99.9 <= 1.5s":        {Expression: "p99.9 <= 1.5s", Metric: "p99.9", Operator: "<=", Value: 1500},
+		"avg<250":              {Expression: "avg<250", Metric: "avg", Operator: "<", Value: 250},
+		"availability>=99.9%":  {Expression: "availability>=99.9%", Metric: "availability", Operator: ">=", Value: 99.9},
+		"rps>500":              {Expression: "rps>500", Metric: "rps", Operator: ">", Value: 500},
+		"errored==0":           {Expression: "errored==0", Metric: "errored", Operator: "==", Value: 0},
+		"GET /users:p95<200ms": {Expression: "GET /users:p95<200ms", Endpoint: "GET /users", Metric: "p95", Operator: "<", Value: 200},
+		"http://localhost:8080/1:failed<1": {
+			Expression: "http://localhost:8080/1:failed<1", Endpoint: "http://localhost:8080/1", Metric: "failed", Operator: "<", Value: 1,
+		},
+	}
+
+	for expression, expected := range cases {
+		threshold, err := service.ParseThreshold(expression)
+		assert.Nil(t, err, expression)
+		assert.Equal(t, expected, threshold, expression)
+	}
+}
+
+func Test_ParseThresholdReturnsAnErrorForInvalidThresholds(t *testing.T) {
+	for _, value := range []string{"", "p95", "p95<", "p95~300ms", "p42<300ms", "p95<fast", "rps>500ms"} {
+		_, err := service.ParseThreshold(value)
+		assert.NotNil(t, err, value)
+	}
+}
+
+func Test_ThresholdEvaluatesAgainstTheResult(t *testing.T) {
+	result := service.SchmokinResult{
+		Transactions:           100,
+		SuccessfulTransactions: 98,
+		FailedTransactions:     1,
+		ErroredTransactions:    1,
+		TransactionRate:        50,
+		ResponseTime:           service.Distribution{P95: int64(250 * time.Millisecond)},
+		Endpoints: []service.EndpointResult{{
+			Name:                   "GET /users",
+			Transactions:           10,
+			SuccessfulTransactions: 10,
+			ResponseTime:           service.Distribution{P95: int64(400 * time.Millisecond)},
+		}},
+	}
+
+	cases := map[string]bool{
+		"p95<300ms":                  true,
+		"p95<200ms":                  false,
+		"availability>=99":           true,
+		"availability>=99.5":         false,
+		"error_rate<=1%":             true,
+		"rps>=50":                    true,
+		"transactions==100":          true,
+		"GET /users:p95<300ms":       false,
+		"GET /users:failed==0":       true,
+		"GET /orders:p95<300ms":      false,
+		"GET /users:success_rate>99": true,
+	}
+
+	for expression, passed := range cases {
+		threshold, err := service.ParseThreshold(expression)
+		assert.Nil(t, err)
+		assert.Equal(t, passed, threshold.Evaluate(result).Passed, expression)
+	}
+}
+
+func Test_ThresholdForAnEndpointWithoutTransactionsIsMissing(t *testing.T) {
+	threshold, err := service.ParseThreshold("GET /orders:p95<300ms")
+	assert.Nil(t, err)
+
+	thresholdResult := threshold.Evaluate(service.SchmokinResult{})
+
+	assert.True(t, thresholdResult.Missing)
+	assert.False(t, thresholdResult.Passed)
+}
+
+func Test_ThresholdIrrecoverable(t *testing.T) {
+	cases := map[string]bool{
+		"max<1s":          true,
+		"errored<=10":     true,
+		"min>5ms":         true,
+		"max>1s":          false,
+		"p95<300ms":       false,
+		"failed>=1":       false,
+		"availability>99": false,
+	}
+
+	for expression, irrecoverable := range cases {
+		threshold, err := service.ParseThreshold(expression)
+		assert.Nil(t, err)
+		assert.Equal(t, irrecoverable, threshold.Irrecoverable(), expression)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Warmup is how long the virtual users make transactions before the run
+// is measured, either for a duration or for a number of transactions.
+type Warmup struct {
+	Duration   time.Duration
+	Iterations int
+}
+
+// Enabled reports whether there is a warm-up to run.
+func (warmup Warmup) Enabled() bool {
+	return warmup.Duration > 0 || warmup.Iterations > 0
+}
+
+// ParseWarmup parses a warm-up given either as a duration e.g. 1m or as a
+// number of transactions e.g. 100, an empty value is no warm-up.
+func ParseWarmup(value string) (Warmup, error) {
+	if value == "" {
+		return Warmup{}, nil
+	}
+	if iterations, err := strconv.Atoi(value); err == nil {
+		if iterations < 0 {
+			return Warmup{}, fmt.Errorf("the warm-up %q must not be negative", value)
+		}
+		return Warmup{Iterations: iterations}, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return Warmup{}, fmt.Errorf("the warm-up %q must be a duration or a number of transactions", value)
+	}
+	if duration < 0 {
+		return Warmup{}, fmt.Errorf("the warm-up %q must not be negative", value)
+	}
+	return Warmup{Duration: duration}, nil
+}
+
+// SplitWarmup returns the warm-up one of the processes runs. Each process
+// warms up for the whole duration while the transactions are shared
+// between them, the first processes making any left over.
+func SplitWarmup(warmup Warmup, process int, processes int) Warmup {
+	iterations := warmup.Iterations / processes
+	if process < warmup.Iterations%processes {
+		iterations++
+	}
+	return Warmup{Duration: warmup.Duration, Iterations: iterations}
+}
+
+// startTransaction returns the stage a virtual user starts a transaction
+// during, -1 when there are no stages or they have all finished. During a
+// warm-up for a number of transactions it takes one from those left and
+// reports false once there are none, taking the last ends the warm-up.
+func (schmokin *SchmokinService) startTransaction() (int, bool) {
+	schmokin.lock.Lock()
+	defer schmokin.lock.Unlock()
+	if schmokin.warmingUp && schmokin.warmup.Iterations > 0 {
+		if schmokin.warmupTransactions >= schmokin.warmup.Iterations {
+			return schmokin.stage, false
+		}
+		schmokin.warmupTransactions++
+		if schmokin.warmupTransactions == schmokin.warmup.Iterations {
+			schmokin.endWarmup()
+		}
+	}
+	return schmokin.stage, true
+}
+
+// endWarmup stops the virtual users starting iterations for the warm-up,
+// those in flight are left to finish. It is called with the lock held.
+func (schmokin *SchmokinService) endWarmup() {
+	select {
+	case <-schmokin.warmupEnded:
+	default:
+		close(schmokin.warmupEnded)
+	}
+}
+
+// runWarmup runs the virtual users with the executor of the run until the
+// warm-up ends, their transactions are recorded apart from those of the
+// run. The virtual users loop over the lines whatever the iterations of the
+// run and the warm-up ends early when the run is interrupted. The virtual
+// users and rates are started again for the run.
+func (schmokin *SchmokinService) runWarmup(lines []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if schmokin.warmup.Duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, schmokin.warmup.Duration)
+	}
+	defer cancel()
+
+	interrupt := schmokin.interrupt
+	ended := make(chan struct{})
+	schmokin.lock.Lock()
+	schmokin.warmingUp = true
+	schmokin.warmupEnded = ended
+	schmokin.warmupStats.startTime = time.Now()
+	schmokin.lock.Unlock()
+	go func() {
+		select {
+		case <-interrupt:
+			schmokin.lock.Lock()
+			schmokin.endWarmup()
+			schmokin.lock.Unlock()
+		case <-ended:
+		}
+	}()
+
+	// The executors stop starting iterations when the warm-up ends as they
+	// would when the run is interrupted
+	schmokin.interrupt = ended
+	schmokin.run(ctx, lines, 0)
+	schmokin.waitGroup.Wait()
+	schmokin.interrupt = interrupt
+
+	schmokin.lock.Lock()
+	defer schmokin.lock.Unlock()
+	schmokin.endWarmup()
+	schmokin.warmingUp = false
+	schmokin.warmupStats.endTime = time.Now()
+	schmokin.users = 0
+	schmokin.idleUsers = 0
+	schmokin.stage = -1
+	schmokin.droppedIterations = 0
+	schmokin.lateIterations = 0
+	for index := range schmokin.stageStats {
+		schmokin.stageStats[index] = newStats()
+	}
+	// A meter ticks until it is stopped, those of the warm-up are stopped
+	// so a worker which serves many runs does not keep them all
+	for _, meter := range []metrics.Meter{schmokin.transactionRate, schmokin.dataSendRate, schmokin.dataReceiveRate} {
+		meter.Stop()
+	}
+	schmokin.transactionRate = metrics.NewMeter()
+	schmokin.dataSendRate = metrics.NewMeter()
+	schmokin.dataReceiveRate = metrics.NewMeter()
+}
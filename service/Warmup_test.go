@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reaandrew/schmokin/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseWarmup(t *testing.T) {
+	cases := map[string]service.Warmup{
+		"":    {},
+		"1m":  {Duration: time.Minute},
+		"100": {Iterations: 100},
+		"0":   {},
+	}
+
+	for value, expected := range cases {
+		warmup, err := service.ParseWarmup(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, warmup, value)
+	}
+}
+
+func Test_ParseWarmupReturnsAnErrorForInvalidWarmups(t *testing.T) {
+	for _, value := range []string{"abc", "-1", "-1m"} {
+		_, err := service.ParseWarmup(value)
+		assert.NotNil(t, err, value)
+	}
+}
+
+func Test_SplitWarmupSharesTheTransactionsBetweenProcesses(t *testing.T) {
+	warmup := service.Warmup{Duration: time.Minute, Iterations: 10}
+
+	assert.Equal(t, service.Warmup{Duration: time.Minute, Iterations: 4}, service.SplitWarmup(warmup, 0, 3))
+	assert.Equal(t, service.Warmup{Duration: time.Minute, Iterations: 3}, service.SplitWarmup(warmup, 1, 3))
+	assert.Equal(t, service.Warmup{Duration: time.Minute, Iterations: 3}, service.SplitWarmup(warmup, 2, 3))
+}
+
+func Test_SchmokinServiceLeavesTheWarmupTransactionsOutOfTheResult(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first requests are slow until the server has warmed up
+		if atomic.AddInt64(&requests, 1) <= 5 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(1).
+		SetIterations(10).
+		SetWarmup(service.Warmup{Iterations: 5}).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Equal(t, int64(15), atomic.LoadInt64(&requests))
+	assert.Equal(t, 10, result.Transactions)
+	assert.Equal(t, int64(10), result.ResponseTime.Count)
+	assert.True(t, result.ResponseTime.Max < int64(100*time.Millisecond), "max %v", time.Duration(result.ResponseTime.Max))
+	assert.Equal(t, 5, result.Warmup.Transactions)
+	assert.Equal(t, int64(5), result.Warmup.ResponseTime.Count)
+	assert.True(t, result.Warmup.ResponseTime.Min >= int64(100*time.Millisecond), "min %v", time.Duration(result.Warmup.ResponseTime.Min))
+	assert.False(t, result.Warmup.EndTime.After(result.StartTime))
+}
+
+func Test_SchmokinServiceWarmsUpForTheDuration(t *testing.T) {
+	server := createDelayedServer(10 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(2).
+		SetIterations(1).
+		SetWarmup(service.Warmup{Duration: 200 * time.Millisecond}).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Equal(t, 2, result.Transactions)
+	assert.True(t, result.Warmup.Transactions > 10, "warm-up transactions %v", result.Warmup.Transactions)
+	assert.InDelta(t, float64(200*time.Millisecond), float64(result.Warmup.EndTime.Sub(result.Warmup.StartTime)), float64(50*time.Millisecond))
+}
+
+func Test_SchmokinServiceWarmsUpAtTheRate(t *testing.T) {
+	server := createDelayedServer(5 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(1).
+		SetRate(50).
+		SetDuration(200 * time.Millisecond).
+		SetWarmup(service.Warmup{Duration: 300 * time.Millisecond}).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	// As fast as one virtual user can would be around 60 transactions
+	assert.InDelta(t, 15, result.Warmup.Transactions, 3)
+	assert.InDelta(t, 10, result.Transactions, 3)
+	assert.Equal(t, int64(0), result.DroppedIterations)
+}
+
+func Test_SchmokinServiceWarmsUpForTheTransactionsAtTheRate(t *testing.T) {
+	server := createDelayedServer(5 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(1).
+		SetRate(50).
+		SetIterations(4).
+		SetWarmup(service.Warmup{Iterations: 6}).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	assert.Equal(t, 6, result.Warmup.Transactions)
+	assert.Equal(t, 4, result.Transactions)
+	// Six iterations at 50 a second take at least 100ms
+	assert.True(t, result.Warmup.EndTime.Sub(result.Warmup.StartTime) >= 100*time.Millisecond)
+}
+
+func Test_SchmokinServiceWarmsUpFollowingTheStages(t *testing.T) {
+	server := createDelayedServer(10 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetStages([]service.Stage{{Duration: 200 * time.Millisecond, Target: 4}}).
+		SetWarmup(service.Warmup{Duration: 150 * time.Millisecond}).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	// The warm-up ramps up to two virtual users before it ends
+	assert.True(t, result.Warmup.Transactions > 0, "warm-up transactions %v", result.Warmup.Transactions)
+	assert.True(t, result.Transactions > 0)
+	assert.Equal(t, result.Transactions, result.Stages[0].Transactions)
+	assert.False(t, result.Stages[0].StartTime.Before(result.StartTime))
+}
+
+func Test_SchmokinServiceRecordsTheIntervalsOfTheWarmup(t *testing.T) {
+	server := createDelayedServer(10 * time.Millisecond)
+	defer server.Close()
+	schmokinService := service.NewSchmokinServiceBuilder().
+		SetWorkers(2).
+		SetDuration(200 * time.Millisecond).
+		SetInterval(50 * time.Millisecond).
+		SetWarmup(service.Warmup{Duration: 200 * time.Millisecond}).
+		Build()
+	result := schmokinService.Execute(context.Background(), []string{server.URL + "/1 -X GET"})
+
+	transactions := map[bool]int{}
+	for index, interval := range result.Intervals {
+		transactions[interval.Warmup] += interval.Transactions
+		if index > 0 {
+			assert.False(t, interval.StartTime.Before(result.Intervals[index-1].StartTime))
+			assert.False(t, interval.Warmup && !result.Intervals[index-1].Warmup, "a warm-up interval after the run's")
+		}
+	}
+	assert.Equal(t, result.Warmup.Transactions, transactions[true])
+	assert.Equal(t, result.Transactions, transactions[false])
+}
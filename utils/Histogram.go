@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// subBucketBits gives 2048 sub buckets per bucket, enough to keep every
+// value to three significant digits.
+const (
+	subBucketBits      = 11
+	subBucketHalfCount = 1 << (subBucketBits - 1)
+	encodingVersion    = 1
+)
+
+// Histogram records every value in the manner of an HDR histogram. Values
+// are counted in buckets whose width doubles as the values grow, so any
+// value is kept to within 0.1% while memory only grows with the range of
+// the values rather than how many are recorded. Only the counts from the
+// lowest to the highest value are kept.
+type Histogram struct {
+	lock       sync.Mutex
+	counts     []int64
+	lowest     int
+	count      int64
+	min        int64
+	max        int64
+	sum        float64
+	sumSquares float64
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+func countsIndex(value int64) int {
+	bucket := bits.Len64(uint64(value)) - subBucketBits
+	if bucket < 0 {
+		bucket = 0
+	}
+	return bucket*subBucketHalfCount + int(value>>uint(bucket))
+}
+
+// highestEquivalentValue returns the largest value counted at index.
+func highestEquivalentValue(index int) int64 {
+	bucket := index/subBucketHalfCount - 1
+	if bucket < 0 {
+		bucket = 0
+	}
+	subBucket := int64(index - bucket*subBucketHalfCount)
+	return subBucket<<uint(bucket) + (int64(1)<<uint(bucket) - 1)
+}
+
+// lowestEquivalentValue returns the smallest value counted at index.
+func lowestEquivalentValue(index int) int64 {
+	if index == 0 {
+		return 0
+	}
+	return highestEquivalentValue(index-1) + 1
+}
+
+// Record adds a value, negative values are recorded as zero.
+func (histogram *Histogram) Record(value int64) {
+	if value < 0 {
+		value = 0
+	}
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	histogram.recordCount(countsIndex(value), 1)
+	if histogram.count == 0 || value < histogram.min {
+		histogram.min = value
+	}
+	if value > histogram.max {
+		histogram.max = value
+	}
+	histogram.count++
+	histogram.sum += float64(value)
+	histogram.sumSquares += float64(value) * float64(value)
+}
+
+// recordCount adds the count at the index, growing the counts kept down
+// or up to it.
+func (histogram *Histogram) recordCount(index int, count int64) {
+	switch {
+	case len(histogram.counts) == 0:
+		histogram.counts = make([]int64, 1)
+		histogram.lowest = index
+	case index < histogram.lowest:
+		counts := make([]int64, histogram.lowest-index+len(histogram.counts))
+		copy(counts[histogram.lowest-index:], histogram.counts)
+		histogram.counts = counts
+		histogram.lowest = index
+	case index-histogram.lowest >= len(histogram.counts):
+		counts := make([]int64, index-histogram.lowest+1)
+		copy(counts, histogram.counts)
+		histogram.counts = counts
+	}
+	histogram.counts[index-histogram.lowest] += count
+}
+
+func (histogram *Histogram) Count() int64 {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	return histogram.count
+}
+
+func (histogram *Histogram) Min() int64 {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	return histogram.min
+}
+
+func (histogram *Histogram) Max() int64 {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	return histogram.max
+}
+
+func (histogram *Histogram) Mean() float64 {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	if histogram.count == 0 {
+		return 0
+	}
+	return histogram.sum / float64(histogram.count)
+}
+
+func (histogram *Histogram) StdDev() float64 {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	if histogram.count == 0 {
+		return 0
+	}
+	mean := histogram.sum / float64(histogram.count)
+	variance := histogram.sumSquares/float64(histogram.count) - mean*mean
+	if variance < 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// ValueAtPercentile returns the value below which the given
+// percentage, between 0 and 100, of the values fall.
+func (histogram *Histogram) ValueAtPercentile(percentile float64) int64 {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	if histogram.count == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(percentile / 100 * float64(histogram.count)))
+	if target < 1 {
+		target = 1
+	}
+	var total int64
+	for index, count := range histogram.counts {
+		total += count
+		if total >= target {
+			value := highestEquivalentValue(histogram.lowest + index)
+			if value > histogram.max {
+				value = histogram.max
+			}
+			if value < histogram.min {
+				value = histogram.min
+			}
+			return value
+		}
+	}
+	return histogram.max
+}
+
+// Merge adds every value recorded by other.
+func (histogram *Histogram) Merge(other *Histogram) {
+	other.lock.Lock()
+	counts := append([]int64{}, other.counts...)
+	lowest := other.lowest
+	count, min, max, sum, sumSquares := other.count, other.min, other.max, other.sum, other.sumSquares
+	other.lock.Unlock()
+	if count == 0 {
+		return
+	}
+
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	for index, value := range counts {
+		if value > 0 {
+			histogram.recordCount(lowest+index, value)
+		}
+	}
+	if histogram.count == 0 || min < histogram.min {
+		histogram.min = min
+	}
+	if max > histogram.max {
+		histogram.max = max
+	}
+	histogram.count += count
+	histogram.sum += sum
+	histogram.sumSquares += sumSquares
+}
+
+// Copy returns a copy of the histogram which is unchanged by the values
+// recorded afterwards.
+func (histogram *Histogram) Copy() *Histogram {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	return &Histogram{
+		counts:     append([]int64{}, histogram.counts...),
+		lowest:     histogram.lowest,
+		count:      histogram.count,
+		min:        histogram.min,
+		max:        histogram.max,
+		sum:        histogram.sum,
+		sumSquares: histogram.sumSquares,
+	}
+}
+
+// Since returns the values recorded after earlier, a copy taken of the
+// histogram before. The min and max are those of the buckets holding the
+// values so are within 0.1% of the values.
+func (histogram *Histogram) Since(earlier *Histogram) *Histogram {
+	since := histogram.Copy()
+	earlier = earlier.Copy()
+	for index, count := range earlier.counts {
+		if count > 0 {
+			since.recordCount(earlier.lowest+index, -count)
+		}
+	}
+	since.count -= earlier.count
+	since.sum -= earlier.sum
+	since.sumSquares -= earlier.sumSquares
+	if since.count == 0 {
+		return NewHistogram()
+	}
+	first := true
+	for index, count := range since.counts {
+		if count <= 0 {
+			continue
+		}
+		if first {
+			since.min = lowestEquivalentValue(since.lowest + index)
+			first = false
+		}
+		since.max = highestEquivalentValue(since.lowest + index)
+	}
+	if since.min < histogram.min {
+		since.min = histogram.min
+	}
+	if since.max > histogram.max {
+		since.max = histogram.max
+	}
+	return since
+}
+
+// Encode serialises the histogram so it can be sent to another process
+// and merged there, see DecodeHistogram. Only the counts which are not
+// zero are written.
+func (histogram *Histogram) Encode() []byte {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+	var buffer bytes.Buffer
+	scratch := make([]byte, binary.MaxVarintLen64)
+	writeUvarint := func(value uint64) {
+		buffer.Write(scratch[:binary.PutUvarint(scratch, value)])
+	}
+	buffer.WriteByte(encodingVersion)
+	writeUvarint(uint64(histogram.count))
+	writeUvarint(uint64(histogram.min))
+	writeUvarint(uint64(histogram.max))
+	writeUvarint(math.Float64bits(histogram.sum))
+	writeUvarint(math.Float64bits(histogram.sumSquares))
+	previous := 0
+	for index, count := range histogram.counts {
+		if count == 0 {
+			continue
+		}
+		writeUvarint(uint64(histogram.lowest + index - previous))
+		writeUvarint(uint64(count))
+		previous = histogram.lowest + index
+	}
+	return buffer.Bytes()
+}
+
+// DecodeHistogram reads a histogram serialised by Encode, no data
+// gives an empty histogram.
+func DecodeHistogram(data []byte) (*Histogram, error) {
+	histogram := NewHistogram()
+	if len(data) == 0 {
+		return histogram, nil
+	}
+	reader := bytes.NewReader(data)
+	version, _ := reader.ReadByte()
+	if version != encodingVersion {
+		return nil, errors.New("unsupported histogram encoding")
+	}
+	header := make([]uint64, 5)
+	for i := range header {
+		value, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, errors.New("truncated histogram")
+		}
+		header[i] = value
+	}
+	histogram.count = int64(header[0])
+	histogram.min = int64(header[1])
+	histogram.max = int64(header[2])
+	histogram.sum = math.Float64frombits(header[3])
+	histogram.sumSquares = math.Float64frombits(header[4])
+	index := 0
+	for reader.Len() > 0 {
+		delta, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, errors.New("truncated histogram")
+		}
+		count, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, errors.New("truncated histogram")
+		}
+		// Checked before adding so a huge delta cannot wrap the index
+		if delta > uint64(countsIndex(math.MaxInt64)-index) {
+			return nil, errors.New("invalid histogram bucket")
+		}
+		index += int(delta)
+		histogram.recordCount(index, int64(count))
+	}
+	return histogram, nil
+}
@@ -0,0 +1,181 @@
+package utils_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/reaandrew/schmokin/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type HistogramPercentileTestCase struct {
+	Percentile float64
+	Expected   int64
+}
+
+func Test_HistogramValueAtPercentile(t *testing.T) {
+	cases := []HistogramPercentileTestCase{
+		{Percentile: 50, Expected: 500000},
+		{Percentile: 75, Expected: 750000},
+		{Percentile: 90, Expected: 900000},
+		{Percentile: 95, Expected: 950000},
+		{Percentile: 99, Expected: 990000},
+		{Percentile: 99.9, Expected: 999000},
+		{Percentile: 100, Expected: 1000000},
+	}
+
+	histogram := utils.NewHistogram()
+	for value := int64(1); value <= 1000000; value++ {
+		histogram.Record(value)
+	}
+
+	for _, testCase := range cases {
+		t.Run(fmt.Sprintf("Test_HistogramValueAtPercentile_%v", testCase.Percentile), func(t *testing.T) {
+			value := histogram.ValueAtPercentile(testCase.Percentile)
+			assert.InEpsilon(t, testCase.Expected, value, 0.001)
+		})
+	}
+}
+
+func Test_HistogramRecordsSmallValuesExactly(t *testing.T) {
+	histogram := utils.NewHistogram()
+	for _, value := range []int64{3, 1, 2, 2000} {
+		histogram.Record(value)
+	}
+
+	assert.Equal(t, int64(4), histogram.Count())
+	assert.Equal(t, int64(1), histogram.Min())
+	assert.Equal(t, int64(2000), histogram.Max())
+	assert.Equal(t, int64(2), histogram.ValueAtPercentile(50))
+	assert.Equal(t, int64(3), histogram.ValueAtPercentile(75))
+	assert.Equal(t, int64(2000), histogram.ValueAtPercentile(99))
+}
+
+func Test_HistogramMeanAndStdDev(t *testing.T) {
+	histogram := utils.NewHistogram()
+	for _, value := range []int64{2, 4, 4, 4, 5, 5, 7, 9} {
+		histogram.Record(value)
+	}
+
+	assert.Equal(t, float64(5), histogram.Mean())
+	assert.Equal(t, float64(2), histogram.StdDev())
+}
+
+func Test_HistogramMerge(t *testing.T) {
+	first := utils.NewHistogram()
+	second := utils.NewHistogram()
+	all := utils.NewHistogram()
+	for value := int64(1); value <= 10000; value++ {
+		if value%3 == 0 {
+			first.Record(value * 1000)
+		} else {
+			second.Record(value * 1000)
+		}
+		all.Record(value * 1000)
+	}
+
+	first.Merge(second)
+
+	assert.Equal(t, all.Count(), first.Count())
+	assert.Equal(t, all.Min(), first.Min())
+	assert.Equal(t, all.Max(), first.Max())
+	assert.True(t, math.Abs(all.Mean()-first.Mean()) < 1e-6)
+	for _, percentile := range []float64{50, 90, 99, 99.9} {
+		assert.Equal(t, all.ValueAtPercentile(percentile), first.ValueAtPercentile(percentile))
+	}
+}
+
+func Test_HistogramMergesValuesBelowAndAboveItsOwn(t *testing.T) {
+	middle := utils.NewHistogram()
+	low := utils.NewHistogram()
+	high := utils.NewHistogram()
+	middle.Record(int64(50 * 1000000))
+	low.Record(int64(5))
+	high.Record(int64(5 * 1000000000))
+
+	middle.Merge(low)
+	middle.Merge(high)
+
+	assert.Equal(t, int64(3), middle.Count())
+	assert.Equal(t, int64(5), middle.ValueAtPercentile(10))
+	assert.InEpsilon(t, int64(50*1000000), middle.ValueAtPercentile(50), 0.001)
+	assert.Equal(t, int64(5*1000000000), middle.ValueAtPercentile(100))
+}
+
+func Test_HistogramCopy(t *testing.T) {
+	histogram := utils.NewHistogram()
+	histogram.Record(1000)
+
+	copied := histogram.Copy()
+	histogram.Record(2000)
+
+	assert.Equal(t, int64(1), copied.Count())
+	assert.Equal(t, int64(1000), copied.Max())
+	assert.Equal(t, int64(2), histogram.Count())
+}
+
+func Test_HistogramSince(t *testing.T) {
+	histogram := utils.NewHistogram()
+	recent := utils.NewHistogram()
+	for value := int64(1); value <= 1000; value++ {
+		histogram.Record(value * 1000000)
+	}
+	earlier := histogram.Copy()
+	for value := int64(1); value <= 100; value++ {
+		histogram.Record(value * 1000)
+		recent.Record(value * 1000)
+	}
+
+	since := histogram.Since(earlier)
+
+	assert.Equal(t, recent.Count(), since.Count())
+	assert.InEpsilon(t, recent.Min(), since.Min(), 0.001)
+	assert.InEpsilon(t, recent.Max(), since.Max(), 0.001)
+	assert.InEpsilon(t, recent.Mean(), since.Mean(), 1e-6)
+	for _, percentile := range []float64{50, 90, 99} {
+		assert.Equal(t, recent.ValueAtPercentile(percentile), since.ValueAtPercentile(percentile))
+	}
+	assert.Equal(t, int64(0), histogram.Since(histogram.Copy()).Count())
+	assert.Equal(t, int64(0), histogram.Since(histogram.Copy()).Max())
+}
+
+func Test_HistogramEncodeAndDecode(t *testing.T) {
+	histogram := utils.NewHistogram()
+	for value := int64(1); value <= 100000; value += 7 {
+		histogram.Record(value * 1000)
+	}
+
+	decoded, err := utils.DecodeHistogram(histogram.Encode())
+
+	assert.Nil(t, err)
+	assert.Equal(t, histogram.Count(), decoded.Count())
+	assert.Equal(t, histogram.Min(), decoded.Min())
+	assert.Equal(t, histogram.Max(), decoded.Max())
+	assert.Equal(t, histogram.Mean(), decoded.Mean())
+	assert.Equal(t, histogram.StdDev(), decoded.StdDev())
+	for _, percentile := range []float64{50, 90, 99, 99.9} {
+		assert.Equal(t, histogram.ValueAtPercentile(percentile), decoded.ValueAtPercentile(percentile))
+	}
+}
+
+func Test_DecodeHistogramRejectsInvalidData(t *testing.T) {
+	_, err := utils.DecodeHistogram([]byte{9, 1, 2})
+
+	assert.NotNil(t, err)
+}
+
+func Test_DecodeHistogramRejectsBucketsBeyondTheLargestValue(t *testing.T) {
+	for _, delta := range []uint64{math.MaxInt64, math.MaxUint64 - 5} {
+		data := utils.NewHistogram().Encode()
+		buffer := make([]byte, binary.MaxVarintLen64)
+		for _, value := range []uint64{10, 1, delta, 1} {
+			data = append(data, buffer[:binary.PutUvarint(buffer, value)]...)
+		}
+
+		_, err := utils.DecodeHistogram(data)
+
+		assert.NotNil(t, err, delta)
+	}
+}
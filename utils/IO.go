@@ -1,21 +1,21 @@
 package utils
 
 import (
-	"bufio"
-	"os"
+	"fmt"
+	"io/ioutil"
+	"strings"
 )
 
+// ReadFileToLines reads the logical lines of a file, see SplitLines.
 func ReadFileToLines(path string) (lines []string, err error) {
-	file, err := os.Open(path)
+	content, err := ioutil.ReadFile(path)
 	if err != nil {
 		return
 	}
-	lines = []string{}
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		lines = append(lines, line)
+	text := strings.Replace(string(content), "\r\n", "\n", -1)
+	lines, err = SplitLines(text)
+	if err != nil {
+		err = fmt.Errorf("%v: %v", path, err)
 	}
-	err = scanner.Err()
 	return
 }
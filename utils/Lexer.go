@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError reports the line of the input on which lexing failed.
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (err ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", err.Line, err.Message)
+}
+
+// SplitLines splits text into its logical lines. Lines ending in a
+// backslash are continued onto the next line, blank lines and # comments
+// are skipped and every line is checked so it can be split with SplitArgs.
+func SplitLines(text string) (lines []string, err error) {
+	lexer := &lexer{input: text, line: 1}
+	for {
+		_, raw, ok, err := lexer.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return lines, nil
+		}
+		lines = append(lines, raw)
+	}
+}
+
+// SplitArgs splits a single logical line into arguments following the
+// quoting rules of a POSIX shell: single quotes preserve everything,
+// double quotes allow \ to escape $ ` " \ and newline, and outside of
+// quotes \ escapes any character.
+func SplitArgs(line string) ([]string, error) {
+	lexer := &lexer{input: line, line: 1}
+	args, _, _, err := lexer.next()
+	if err != nil {
+		return nil, err
+	}
+	_, _, ok, err := lexer.next()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return nil, ParseError{Line: lexer.line, Message: "expected a single line"}
+	}
+	return args, nil
+}
+
+type lexer struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (lexer *lexer) eof() bool {
+	return lexer.pos >= len(lexer.input)
+}
+
+func (lexer *lexer) peek() byte {
+	return lexer.input[lexer.pos]
+}
+
+func (lexer *lexer) continuation() bool {
+	return strings.HasPrefix(lexer.input[lexer.pos:], "\\\n")
+}
+
+func (lexer *lexer) skipComment() {
+	for !lexer.eof() && lexer.peek() != '\n' {
+		lexer.pos++
+	}
+}
+
+// next returns the arguments and raw text of the next non blank line.
+func (lexer *lexer) next() (args []string, raw string, ok bool, err error) {
+	lexer.skipBlank()
+	if lexer.eof() {
+		return
+	}
+	start := lexer.pos
+	for !lexer.eof() {
+		switch c := lexer.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			lexer.pos++
+		case lexer.continuation():
+			lexer.pos += 2
+			lexer.line++
+		case c == '\n':
+			raw = lexer.input[start:lexer.pos]
+			lexer.pos++
+			lexer.line++
+			return args, raw, true, nil
+		case c == '#':
+			lexer.skipComment()
+		default:
+			word, err := lexer.word()
+			if err != nil {
+				return nil, "", false, err
+			}
+			args = append(args, word)
+		}
+	}
+	return args, lexer.input[start:], true, nil
+}
+
+// skipBlank moves past whitespace, blank lines and comment lines.
+func (lexer *lexer) skipBlank() {
+	for !lexer.eof() {
+		switch c := lexer.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			lexer.pos++
+		case c == '\n':
+			lexer.pos++
+			lexer.line++
+		case lexer.continuation():
+			lexer.pos += 2
+			lexer.line++
+		case c == '#':
+			lexer.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (lexer *lexer) word() (string, error) {
+	var word strings.Builder
+	for !lexer.eof() {
+		c := lexer.peek()
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			return word.String(), nil
+		case '\\':
+			lexer.pos++
+			if lexer.eof() {
+				return "", ParseError{Line: lexer.line, Message: "unexpected end of input after \\"}
+			}
+			if lexer.peek() == '\n' {
+				lexer.line++
+			} else {
+				word.WriteByte(lexer.peek())
+			}
+			lexer.pos++
+		case '\'':
+			if err := lexer.singleQuoted(&word); err != nil {
+				return "", err
+			}
+		case '"':
+			if err := lexer.doubleQuoted(&word); err != nil {
+				return "", err
+			}
+		default:
+			word.WriteByte(c)
+			lexer.pos++
+		}
+	}
+	return word.String(), nil
+}
+
+func (lexer *lexer) singleQuoted(word *strings.Builder) error {
+	line := lexer.line
+	lexer.pos++
+	for !lexer.eof() {
+		c := lexer.peek()
+		lexer.pos++
+		if c == '\'' {
+			return nil
+		}
+		if c == '\n' {
+			lexer.line++
+		}
+		word.WriteByte(c)
+	}
+	return ParseError{Line: line, Message: "unterminated single quote"}
+}
+
+func (lexer *lexer) doubleQuoted(word *strings.Builder) error {
+	line := lexer.line
+	lexer.pos++
+	for !lexer.eof() {
+		c := lexer.peek()
+		lexer.pos++
+		switch c {
+		case '"':
+			return nil
+		case '\n':
+			lexer.line++
+			word.WriteByte(c)
+		case '\\':
+			if lexer.eof() {
+				continue
+			}
+			switch escaped := lexer.peek(); escaped {
+			case '\n':
+				lexer.pos++
+				lexer.line++
+			case '$', '`', '"', '\\':
+				lexer.pos++
+				word.WriteByte(escaped)
+			default:
+				word.WriteByte(c)
+			}
+		default:
+			word.WriteByte(c)
+		}
+	}
+	return ParseError{Line: line, Message: "unterminated double quote"}
+}
@@ -0,0 +1,50 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/reaandrew/schmokin/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type SplitArgsTestCase struct {
+	Line         string
+	ExpectedArgs []string
+}
+
+func Test_SplitArgs(t *testing.T) {
+	cases := []SplitArgsTestCase{
+		{Line: `http://localhost:8080/1`, ExpectedArgs: []string{"http://localhost:8080/1"}},
+		{Line: `  http://a  -X  GET `, ExpectedArgs: []string{"http://a", "-X", "GET"}},
+		{Line: `http://a -H "Content-Type: application/json"`, ExpectedArgs: []string{"http://a", "-H", "Content-Type: application/json"}},
+		{Line: `http://a -d '{"name": "a b"}'`, ExpectedArgs: []string{"http://a", "-d", `{"name": "a b"}`}},
+		{Line: `http://a -d "say \"hi\" \$HOME \n"`, ExpectedArgs: []string{"http://a", "-d", `say "hi" $HOME \n`}},
+		{Line: `http://a -d a\ b\'c`, ExpectedArgs: []string{"http://a", "-d", "a b'c"}},
+		{Line: `http://a -d ''`, ExpectedArgs: []string{"http://a", "-d", ""}},
+		{Line: "http://a \\\n  -X GET", ExpectedArgs: []string{"http://a", "-X", "GET"}},
+		{Line: `http://a/#fragment -X GET # a comment`, ExpectedArgs: []string{"http://a/#fragment", "-X", "GET"}},
+	}
+
+	for _, testCase := range cases {
+		args, err := utils.SplitArgs(testCase.Line)
+		assert.Nil(t, err, testCase.Line)
+		assert.Equal(t, testCase.ExpectedArgs, args, testCase.Line)
+	}
+}
+
+func Test_SplitLinesSkipsBlankLinesAndComments(t *testing.T) {
+	text := "# urls\n\nhttp://a\n  # indented comment\nhttp://b \\\n  -X GET\n\n"
+
+	lines, err := utils.SplitLines(text)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"http://a", "http://b \\\n  -X GET"}, lines)
+}
+
+func Test_SplitLinesReturnsTheLineOfAnError(t *testing.T) {
+	text := "http://a\nhttp://b \\\n  -d 'unterminated\nhttp://c"
+
+	_, err := utils.SplitLines(text)
+
+	assert.Equal(t, utils.ParseError{Line: 3, Message: "unterminated single quote"}, err)
+}